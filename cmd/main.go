@@ -1,14 +1,18 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"zencache/internal/config"
 	"zencache/internal/transport/http"
 )
 
 func main() {
+	configFile := flag.String("config", "config.json", "配置文件路径")
+	flag.Parse()
+
 	// 加载配置文件
-	conf, err := config.LoadConfig("config.json")
+	conf, err := config.LoadConfig(*configFile)
 	if err != nil {
 		log.Printf("加载配置文件失败，使用默认配置: %v", err)
 		conf = &config.DefaultConfig
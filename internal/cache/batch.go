@@ -0,0 +1,251 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+	"zencache/internal/peers"
+)
+
+// BatchGetter 支持一次回源多个key，返回结果中不存在的key视为未找到，error非空表示整批回源失败
+type BatchGetter interface {
+	GetMany(ctx context.Context, keys []string) (map[string][]byte, error)
+}
+
+type BatchGetterFunc func(context.Context, []string) (map[string][]byte, error)
+
+func (f BatchGetterFunc) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	return f(ctx, keys)
+}
+
+// WithBatchGetter 设置批量回源函数，GetMany中本地未命中的key通过一次调用加载，
+// AddGroup传入的Getter实现了BatchGetter时会自动使用
+func WithBatchGetter(getter BatchGetter) GroupOption {
+	return func(g *Group) {
+		g.batchGetter = getter
+	}
+}
+
+// Result 批量获取时单个key的结果
+type Result struct {
+	Value ByteView
+	Err   error
+}
+
+// GetMany 批量获取缓存，未命中的key按所属节点分组，每个远程节点只发送一次批量请求，
+// 返回结果包含去重后的每个key
+func (g *Group) GetMany(ctx context.Context, keys []string) map[string]Result {
+	return g.getMany(ctx, keys, true)
+}
+
+// GetManyLocal 与GetMany相同，但只从本地缓存或回源获取，用于处理其他节点转发来的批量请求
+func (g *Group) GetManyLocal(ctx context.Context, keys []string) map[string]Result {
+	return g.getMany(ctx, keys, false)
+}
+
+func (g *Group) getMany(ctx context.Context, keys []string, forward bool) map[string]Result {
+	results := make(map[string]Result, len(keys))
+	var mutex sync.Mutex
+	set := func(key string, r Result) {
+		mutex.Lock()
+		results[key] = r
+		mutex.Unlock()
+	}
+
+	// 先查本地缓存，未命中的key按所属节点分组
+	seen := make(map[string]struct{}, len(keys))
+	var local []string
+	remote := make(map[peers.PeerGetter][]string)
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if key == "" {
+			results[key] = Result{Err: ErrKeyIsNil}
+			continue
+		}
+		g.stats.gets.Add(1)
+		if byteView, ok, err := g.lookup(key); ok {
+			g.stats.hits.Add(1)
+			results[key] = Result{Value: byteView, Err: err}
+			continue
+		}
+		if forward {
+			if byteView, ok := g.hotCache.get(key); ok {
+				g.stats.hits.Add(1)
+				results[key] = Result{Value: byteView}
+				continue
+			}
+		}
+		g.stats.misses.Add(1)
+		if !forward {
+			local = append(local, key)
+			continue
+		}
+		if peer, ok := g.pickPeer(key); ok {
+			remote[peer] = append(remote[peer], key)
+			continue
+		}
+		local = append(local, key)
+	}
+
+	var wg sync.WaitGroup
+	for peer, keys := range remote {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.getManyFromPeer(ctx, peer, keys, set)
+		}()
+	}
+	g.getManyLocally(ctx, local, set)
+	wg.Wait()
+	return results
+}
+
+// getManyFromPeer 通过一次请求从远程节点获取，节点不支持批量获取时逐个获取
+func (g *Group) getManyFromPeer(ctx context.Context, peer peers.PeerGetter, keys []string, set func(string, Result)) {
+	batch, ok := peer.(peers.PeerBatchGetter)
+	if !ok {
+		for _, key := range keys {
+			byteView, err := g.load(ctx, key)
+			set(key, Result{Value: byteView, Err: err})
+		}
+		return
+	}
+	values, err := batch.GetMany(ctx, g.name, keys)
+	for _, key := range keys {
+		if err != nil {
+			g.stats.recordPeerLoad(err)
+			set(key, Result{Err: err})
+			continue
+		}
+		r, ok := values[key]
+		if !ok {
+			r.Err = ErrKeyNotFound
+		}
+		g.stats.recordPeerLoad(r.Err)
+		if r.Err != nil {
+			set(key, Result{Err: r.Err})
+			continue
+		}
+		byteView := NewByteView(r.Value)
+		g.hotCache.maybeAdd(key, byteView)
+		set(key, Result{Value: byteView})
+	}
+}
+
+// getManyLocally 通过BatchGetter一次回源所有key，未设置BatchGetter时逐个回源
+func (g *Group) getManyLocally(ctx context.Context, keys []string, set func(string, Result)) {
+	if len(keys) == 0 {
+		return
+	}
+	if g.batchGetter == nil {
+		for _, key := range keys {
+			byteView, err := g.loadLocally(ctx, key)
+			set(key, Result{Value: byteView, Err: err})
+		}
+		return
+	}
+	values, err := g.batchGetter.GetMany(ctx, keys)
+	for _, key := range keys {
+		g.stats.recordLoad(err)
+		if err != nil {
+			g.addNegative(key, err)
+			set(key, Result{Err: err})
+			continue
+		}
+		bs, ok := values[key]
+		if !ok {
+			g.addNegative(key, ErrKeyNotFound)
+			set(key, Result{Err: ErrKeyNotFound})
+			continue
+		}
+		byteView := NewByteView(bs)
+		g.cache.add(key, byteView, g.expireAt(0))
+		set(key, Result{Value: byteView})
+	}
+}
+
+// SetMany 批量添加缓存，ttl<=0时使用Group的默认过期时间，属于远程节点的key按节点分组，
+// 每个节点只发送一次批量请求，返回写入失败的key及原因。与AddWithTTL相同，数据源由key所属的节点写入，
+// 写入数据源失败的key不会更新缓存
+func (g *Group) SetMany(entries map[string]ByteView, ttl time.Duration) map[string]error {
+	failed := make(map[string]error)
+	remote := make(map[peers.PeerGetter]map[string][]byte)
+	for key, value := range entries {
+		if key == "" {
+			failed[key] = ErrKeyIsNil
+			continue
+		}
+		peer, ok := g.pickPeer(key)
+		if !ok {
+			if err := g.AddLocal(key, value, ttl); err != nil {
+				failed[key] = err
+			}
+			continue
+		}
+		// key属于远程节点，写入该节点并删除本地可能存在的旧数据
+		g.cache.remove(key)
+		g.hotCache.remove(key)
+		if remote[peer] == nil {
+			remote[peer] = make(map[string][]byte)
+		}
+		remote[peer][key] = value.ByteSlices()
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for peer, batch := range remote {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs := g.setManyOnPeer(peer, batch, ttl)
+			mutex.Lock()
+			defer mutex.Unlock()
+			for key, err := range errs {
+				failed[key] = err
+			}
+		}()
+	}
+	wg.Wait()
+	return failed
+}
+
+// SetManyLocal 只写入本地缓存，不转发给远程节点，用于处理其他节点转发来的批量请求
+func (g *Group) SetManyLocal(entries map[string]ByteView, ttl time.Duration) map[string]error {
+	failed := make(map[string]error)
+	for key, value := range entries {
+		if err := g.AddLocal(key, value, ttl); err != nil {
+			failed[key] = err
+		}
+	}
+	return failed
+}
+
+// setManyOnPeer 通过一次请求写入远程节点，节点不支持批量写入时逐个写入
+func (g *Group) setManyOnPeer(peer peers.PeerGetter, entries map[string][]byte, ttl time.Duration) map[string]error {
+	failed := make(map[string]error)
+	if batch, ok := peer.(peers.PeerBatchSetter); ok {
+		errs, err := batch.SetMany(g.name, entries, ttl)
+		for key := range entries {
+			if err != nil {
+				failed[key] = err
+			} else if errs[key] != nil {
+				failed[key] = errs[key]
+			}
+		}
+		return failed
+	}
+	setter, ok := peer.(peers.PeerSetter)
+	for key, value := range entries {
+		if !ok {
+			failed[key] = ErrPeerUnsupported
+			continue
+		}
+		if err := setter.Set(g.name, key, value, ttl); err != nil {
+			failed[key] = err
+		}
+	}
+	return failed
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"zencache/internal/peers"
+)
+
+// prefixPicker 将以"r"开头的key分配给peer，其余key由本地处理
+type prefixPicker struct {
+	peer peers.PeerGetter
+}
+
+func (p prefixPicker) PickPeer(key string) (peers.PeerGetter, bool) {
+	return p.peer, strings.HasPrefix(key, "r")
+}
+
+type testBatchPeer struct {
+	testPeer
+	batches atomic.Int32
+}
+
+func (p *testBatchPeer) GetMany(ctx context.Context, group string, keys []string) (map[string]peers.Result, error) {
+	p.batches.Add(1)
+	results := make(map[string]peers.Result, len(keys))
+	for _, key := range keys {
+		if key == "r-missing" {
+			results[key] = peers.Result{Err: ErrKeyNotFound}
+			continue
+		}
+		results[key] = peers.Result{Value: []byte("peer " + key)}
+	}
+	return results, nil
+}
+
+func TestGroup_GetMany(t *testing.T) {
+	var batches atomic.Int32
+	var loaded []string
+	peer := &testBatchPeer{}
+	e := NewEngine()
+	e.AddGroup("many", nil, 1<<10, WithSweepInterval(0), WithPeersPicker(prefixPicker{peer}),
+		WithBatchGetter(BatchGetterFunc(func(ctx context.Context, keys []string) (map[string][]byte, error) {
+			batches.Add(1)
+			loaded = append(loaded, keys...)
+			values := make(map[string][]byte)
+			for _, key := range keys {
+				if key != "l-missing" {
+					values[key] = []byte("local " + key)
+				}
+			}
+			return values, nil
+		})))
+	g := e.GetGroup("many")
+	g.AddLocal("l-cached", NewByteView([]byte("cached")), 0)
+
+	keys := []string{"l-1", "l-2", "l-cached", "l-missing", "r-1", "r-2", "r-missing", "l-1", ""}
+	results := g.GetMany(context.Background(), keys)
+
+	if len(results) != len(keys)-1 {
+		t.Errorf("expected %d deduplicated results, got %d", len(keys)-1, len(results))
+	}
+	want := map[string]string{
+		"l-1":      "local l-1",
+		"l-2":      "local l-2",
+		"l-cached": "cached",
+		"r-1":      "peer r-1",
+		"r-2":      "peer r-2",
+	}
+	for key, value := range want {
+		if r := results[key]; r.Err != nil || r.Value.String() != value {
+			t.Errorf("%s: expected %q, got %q %v", key, value, r.Value.String(), r.Err)
+		}
+	}
+	for _, key := range []string{"l-missing", "r-missing"} {
+		if err := results[key].Err; err != ErrKeyNotFound {
+			t.Errorf("%s: expected ErrKeyNotFound, got %v", key, err)
+		}
+	}
+	if err := results[""].Err; err != ErrKeyIsNil {
+		t.Errorf("expected ErrKeyIsNil for empty key, got %v", err)
+	}
+	if n := batches.Load(); n != 1 || len(loaded) != 3 {
+		t.Errorf("expected one batch load of 3 local misses, got %d batches %v", n, loaded)
+	}
+	if n := peer.batches.Load(); n != 1 || peer.calls.Load() != 0 {
+		t.Errorf("expected one batched peer call, got %d batches %d gets", n, peer.calls.Load())
+	}
+
+	// 已加载的key从本地缓存返回
+	g.GetMany(context.Background(), []string{"l-1", "l-2"})
+	if n := batches.Load(); n != 1 {
+		t.Errorf("expected cached keys not to be reloaded, got %d batches", n)
+	}
+}
+
+func TestGroup_SetMany(t *testing.T) {
+	peer := &testSetter{stored: make(map[string]string)}
+	e := NewEngine()
+	e.AddGroup("set-many", nil, 1<<10, WithSweepInterval(0), WithPeersPicker(prefixPicker{peer}))
+	g := e.GetGroup("set-many")
+
+	failed := g.SetMany(map[string]ByteView{
+		"l-1": NewByteView([]byte("a")),
+		"r-1": NewByteView([]byte("b")),
+		"":    NewByteView([]byte("c")),
+	}, 0)
+	if len(failed) != 1 || failed[""] != ErrKeyIsNil {
+		t.Errorf("expected only the empty key to fail, got %v", failed)
+	}
+	if v, err := g.GetLocal("l-1"); err != nil || v.String() != "a" {
+		t.Errorf("expected local key stored locally, got %q %v", v.String(), err)
+	}
+	if peer.stored["set-many/r-1"] != "b" {
+		t.Errorf("expected remote key stored on peer, got %v", peer.stored)
+	}
+}
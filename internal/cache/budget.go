@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrMemoryBudgetExceeded Engine的总容量不足以再创建Group
+	ErrMemoryBudgetExceeded = errors.New("MemoryBudgetExceeded")
+	// ErrTooManyGroups 未在配置中声明的Group数量达到上限
+	ErrTooManyGroups = errors.New("TooManyGroups")
+)
+
+// defaultRebalanceInterval 默认重新分配各Group容量的间隔
+const defaultRebalanceInterval = 10 * time.Second
+
+// groupBudget Group在Engine总容量中的份额，只在持有Engine写锁时访问
+type groupBudget struct {
+	requested int64 // 创建或调整容量时指定的字节数，分到的容量不超过该值，0表示不限制
+	minBytes  int64 // 至少分到的容量
+	weight    int   // 分配剩余容量的权重，0按1处理
+	lastGets  int64 // 上次分配时的获取次数，用于计算近期的访问量
+}
+
+// WithBudget 设置Group在Engine总容量中的份额，至少分到minBytes，
+// 剩余容量按weight和近期访问量分配，只在Engine配置了总容量上限时生效
+func WithBudget(minBytes int64, weight int) GroupOption {
+	return func(g *Group) {
+		g.budget.minBytes = minBytes
+		g.budget.weight = weight
+	}
+}
+
+// admitLocked 检查总容量能否满足新Group的最小容量，old为将被替换的同名Group，调用方需持有写锁
+func (e *Engine) admitLocked(g *Group, old *Group) error {
+	if e.maxTotalBytes <= 0 {
+		return nil
+	}
+	var reserved int64
+	for _, other := range e.groups {
+		if other != old {
+			reserved += other.budget.minBytes
+		}
+	}
+	// 没有最小容量的Group只能分到剩余容量，剩余容量为0时同样拒绝
+	if reserved+g.budget.minBytes > e.maxTotalBytes || (g.budget.minBytes == 0 && reserved >= e.maxTotalBytes) {
+		return ErrMemoryBudgetExceeded
+	}
+	return nil
+}
+
+// admitUnconfiguredLocked 检查未在配置中声明的Group数量是否达到上限，调用方需持有写锁
+func (e *Engine) admitUnconfiguredLocked(name string) error {
+	if e.conf == nil || e.conf.MaxGroups <= 0 {
+		return nil
+	}
+	if _, ok := e.conf.Groups[name]; ok {
+		return nil
+	}
+	n := 0
+	for other := range e.groups {
+		if _, ok := e.conf.Groups[other]; !ok {
+			n++
+		}
+	}
+	if n >= e.conf.MaxGroups {
+		return ErrTooManyGroups
+	}
+	return nil
+}
+
+// Rebalance 立即重新分配各Group的容量，并开始统计下一个周期的访问量
+func (e *Engine) Rebalance() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.rebalanceLocked(true)
+}
+
+// rebalanceLocked 每个Group先分到最小容量，剩余容量按权重乘以近期访问量的比例分配，
+// 分到的容量达到requested的Group不再参与，多出的容量分给其他Group，调用方需持有写锁。
+// advance为true时以本次的访问次数作为下一个周期的起点，只在定期分配时使用，
+// 创建、删除Group和调整容量时不重置，避免频繁创建Group使访问量的统计失效
+func (e *Engine) rebalanceLocked(advance bool) {
+	if e.maxTotalBytes <= 0 || len(e.groups) == 0 {
+		return
+	}
+	alloc := make(map[*Group]int64, len(e.groups))
+	scores := make(map[*Group]float64, len(e.groups))
+	remaining := e.maxTotalBytes
+	for _, g := range e.groups {
+		alloc[g] = g.budget.minBytes
+		remaining -= g.budget.minBytes
+		gets := g.stats.gets.Load()
+		scores[g] = float64(max(g.budget.weight, 1)) * float64(1+gets-g.budget.lastGets)
+		if advance {
+			g.budget.lastGets = gets
+		}
+	}
+	saturated := func(g *Group) bool {
+		return g.budget.requested > 0 && alloc[g] >= g.budget.requested
+	}
+	for remaining > 0 {
+		var total float64
+		for g, score := range scores {
+			if !saturated(g) {
+				total += score
+			}
+		}
+		if total == 0 {
+			break
+		}
+		var given int64
+		for g, score := range scores {
+			if saturated(g) {
+				continue
+			}
+			share := int64(float64(remaining) * score / total)
+			if g.budget.requested > 0 {
+				share = min(share, g.budget.requested-alloc[g])
+			}
+			alloc[g] += share
+			given += share
+		}
+		if given == 0 {
+			break
+		}
+		remaining -= given
+	}
+	for g, maxBytes := range alloc {
+		if maxEntries, current := g.cache.capacity(); current != maxBytes {
+			g.cache.resize(maxEntries, maxBytes)
+		}
+	}
+}
+
+// rebalanceLoop 定期根据近期访问量重新分配容量，空闲Group的容量会逐渐分给繁忙的Group
+func (e *Engine) rebalanceLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.Rebalance()
+		case <-e.stopRebalance:
+			return
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"testing"
+	"zencache/internal/config"
+)
+
+func TestEngine_BudgetAdmission(t *testing.T) {
+	e := NewEngineWithConfig(&config.CacheConfig{
+		MaxTotalBytes: 1000,
+		Groups: map[string]config.GroupConfig{
+			"a": {MinBytes: 600},
+			"b": {MinBytes: 400},
+		},
+	})
+	defer e.Close()
+
+	for _, name := range []string{"a", "b"} {
+		if err := e.AddGroup(name, nil, 1000, WithSweepInterval(0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 最小容量已经占满总容量
+	if err := e.AddGroup("c", nil, 1000, WithSweepInterval(0)); err != ErrMemoryBudgetExceeded {
+		t.Errorf("expected ErrMemoryBudgetExceeded, got %v", err)
+	}
+	if _, _, err := e.GetOrAddGroup("c", nil, 1000); err != ErrMemoryBudgetExceeded {
+		t.Errorf("expected GetOrAddGroup to be rejected, got %v", err)
+	}
+	// 替换时不计算被替换Group的最小容量
+	if _, err := e.ReplaceGroup("b", nil, 1000, WithSweepInterval(0)); err != nil {
+		t.Errorf("expected replacing a group to be admitted, got %v", err)
+	}
+
+	e.RemoveGroup("b")
+	if err := e.AddGroup("c", nil, 1000, WithSweepInterval(0)); err != nil {
+		t.Errorf("expected removed group's budget to be reusable, got %v", err)
+	}
+}
+
+func TestEngine_BudgetRebalance(t *testing.T) {
+	e := NewEngineWithConfig(&config.CacheConfig{MaxTotalBytes: 1000})
+	defer e.Close()
+	e.AddGroup("busy", nil, 0, WithSweepInterval(0), WithBudget(100, 1))
+	e.AddGroup("idle", nil, 0, WithSweepInterval(0), WithBudget(100, 1))
+	e.AddGroup("capped", nil, 50, WithSweepInterval(0))
+	busy, idle, capped := e.GetGroup("busy"), e.GetGroup("idle"), e.GetGroup("capped")
+
+	total := func() int64 {
+		var n int64
+		for _, s := range e.Stats() {
+			n += s.MaxBytes
+		}
+		return n
+	}
+	if n := total(); n > 1000 || n < 990 {
+		t.Errorf("expected the whole budget to be allocated, got %d", n)
+	}
+	if n := capped.Stats().MaxBytes; n != 50 {
+		t.Errorf("expected group to be limited to its requested size, got %d", n)
+	}
+
+	for range 100 {
+		busy.Get("k")
+	}
+	e.Rebalance()
+	b, i := busy.Stats().MaxBytes, idle.Stats().MaxBytes
+	if b <= i || i < 100 {
+		t.Errorf("expected busy group to get more than idle one above its minimum, got busy=%d idle=%d", b, i)
+	}
+	if n := total(); n > 1000 {
+		t.Errorf("expected allocation within budget, got %d", n)
+	}
+
+	// 一段时间没有访问后平分剩余容量
+	e.Rebalance()
+	b, i = busy.Stats().MaxBytes, idle.Stats().MaxBytes
+	if b != i {
+		t.Errorf("expected equal shares once both groups are idle, got busy=%d idle=%d", b, i)
+	}
+}
+
+func TestEngine_BudgetSignalSurvivesGroupCreation(t *testing.T) {
+	e := NewEngineWithConfig(&config.CacheConfig{MaxTotalBytes: 1000})
+	defer e.Close()
+	e.AddGroup("busy", nil, 0, WithSweepInterval(0))
+	e.AddGroup("idle", nil, 0, WithSweepInterval(0))
+	busy, idle := e.GetGroup("busy"), e.GetGroup("idle")
+
+	for range 100 {
+		busy.Get("k")
+	}
+	// 创建Group时的重新分配不会清空近期的访问量
+	e.AddGroup("new", nil, 0, WithSweepInterval(0))
+	e.Rebalance()
+	if b, i := busy.Stats().MaxBytes, idle.Stats().MaxBytes; b <= i {
+		t.Errorf("expected busy group to keep its share after a group was created, got busy=%d idle=%d", b, i)
+	}
+}
+
+func TestEngine_MaxGroups(t *testing.T) {
+	e := NewEngineWithConfig(&config.CacheConfig{
+		MaxGroups: 2,
+		Groups:    map[string]config.GroupConfig{"configured": {}},
+	})
+	defer e.Close()
+
+	for _, name := range []string{"a", "b"} {
+		if _, _, err := e.GetOrAddGroup(name, nil, 100, WithSweepInterval(0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := e.GetOrAddGroup("c", nil, 100, WithSweepInterval(0)); err != ErrTooManyGroups {
+		t.Errorf("expected ErrTooManyGroups, got %v", err)
+	}
+	if g, _, err := e.GetOrAddGroup("a", nil, 100); err != nil || g == nil {
+		t.Errorf("expected existing groups to stay reachable, got %v", err)
+	}
+	if _, _, err := e.GetOrAddGroup("configured", nil, 100, WithSweepInterval(0)); err != nil {
+		t.Errorf("expected configured groups not to count against the limit, got %v", err)
+	}
+	e.RemoveGroup("b")
+	if _, _, err := e.GetOrAddGroup("c", nil, 100, WithSweepInterval(0)); err != nil {
+		t.Errorf("expected a removed group to free its slot, got %v", err)
+	}
+}
@@ -1,22 +1,63 @@
 package cache
 
+import (
+	"bytes"
+	"io"
+)
+
 // 只读结构 防止内存修改
 type ByteView struct {
 	bytes []byte
 }
 
+var _ io.WriterTo = ByteView{}
+
 func (bv ByteView) Len() int {
 	return len(bv.bytes)
 }
 func NewByteView(b []byte) ByteView {
 	return ByteView{cloneBytes(b)}
 }
+
+// ByteSlices 返回数据的副本，只读访问时优先使用Reader、WriteTo或Copy避免复制
 func (bv ByteView) ByteSlices() []byte {
 	return cloneBytes(bv.bytes)
 }
 func (bv ByteView) String() string {
 	return string(bv.bytes)
 }
+
+// At 返回第i个字节
+func (bv ByteView) At(i int) byte {
+	return bv.bytes[i]
+}
+
+// Slice 返回[from, to)范围的视图，与原视图共享底层数据
+func (bv ByteView) Slice(from, to int) ByteView {
+	return ByteView{bv.bytes[from:to]}
+}
+
+// Equal 判断两个视图的数据是否相同
+func (bv ByteView) Equal(other ByteView) bool {
+	return bytes.Equal(bv.bytes, other.bytes)
+}
+
+// Copy 将数据复制到dst，返回复制的字节数
+func (bv ByteView) Copy(dst []byte) int {
+	return copy(dst, bv.bytes)
+}
+
+// Reader 返回读取数据的io.Reader，不复制数据
+func (bv ByteView) Reader() io.Reader {
+	return bytes.NewReader(bv.bytes)
+}
+
+// WriteTo 将数据直接写入w，不复制数据，io.Writer的约定保证w不会修改或持有数据
+func (bv ByteView) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(bv.bytes)
+	return int64(n), err
+}
+
 func cloneBytes(b []byte) []byte {
 	c := make([]byte, len(b))
 	copy(c, b)
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestByteView_ReadOnly(t *testing.T) {
+	src := []byte("hello world")
+	bv := NewByteView(src)
+	src[0] = 'H'
+	if bv.String() != "hello world" {
+		t.Errorf("expected view to be isolated from source, got %q", bv.String())
+	}
+
+	got := bv.ByteSlices()
+	got[0] = 'H'
+	dst := make([]byte, 5)
+	if n := bv.Copy(dst); n != 5 || string(dst) != "hello" {
+		t.Errorf("expected to copy 5 bytes, got %d %q", n, dst)
+	}
+	dst[0] = 'H'
+	if bv.At(0) != 'h' {
+		t.Error("expected view to be isolated from returned copies")
+	}
+}
+
+func TestByteView_Slice(t *testing.T) {
+	bv := NewByteView([]byte("hello world"))
+	world := bv.Slice(6, 11)
+	if world.String() != "world" || world.Len() != 5 || world.At(0) != 'w' {
+		t.Errorf("unexpected slice %q", world.String())
+	}
+	if !world.Equal(NewByteView([]byte("world"))) || world.Equal(bv) {
+		t.Error("expected Equal to compare contents")
+	}
+}
+
+func TestByteView_ReaderWriteTo(t *testing.T) {
+	bv := NewByteView([]byte("hello world"))
+	got, err := io.ReadAll(bv.Reader())
+	if err != nil || string(got) != "hello world" {
+		t.Errorf("expected reader to return the value, got %q %v", got, err)
+	}
+
+	var buf bytes.Buffer
+	if n, err := bv.WriteTo(&buf); err != nil || n != 11 || buf.String() != "hello world" {
+		t.Errorf("expected WriteTo to write the value, got %d %q %v", n, buf.String(), err)
+	}
+}
+
+func BenchmarkByteView_WriteTo(b *testing.B) {
+	bv := NewByteView(make([]byte, 1<<20))
+	b.ReportAllocs()
+	for range b.N {
+		bv.WriteTo(io.Discard)
+	}
+}
@@ -1,34 +1,359 @@
 package cache
 
 import (
+	"hash/maphash"
+	"slices"
 	"sync"
-	"zencache/internal/lru"
+	"sync/atomic"
+	"time"
+	"zencache/internal/eviction"
 )
 
-// 支持并发缓存
+const (
+	// defaultShards 默认分片数
+	defaultShards = 16
+	// 每个分片至少分到的字节数和条目数，容量太小时减少分片数，避免分片间不均衡导致过早淘汰
+	minShardBytes   = 64 << 10
+	minShardEntries = 16
+)
+
+// 支持并发缓存，按key的哈希分成多个互相独立的分片，每个分片有自己的锁和淘汰策略
 type cache struct {
-	lru      *lru.Cache
-	mu       sync.RWMutex
-	maxBytes int64
+	shards     []*shard
+	seed       maphash.Seed
+	newPolicy  eviction.Factory // 为空时使用LRU
+	nShards    int              // 分片数，0表示根据容量自动选择
+	sizeMu     sync.Mutex       // 保护运行时调整的容量
+	maxEntries int              // 最大条目数，0表示不限制
+	maxBytes   int64
+	limit      atomic.Int64  // 所有分片共享的字节数上限，与maxBytes相同，加锁时也可以读取
+	used       atomic.Int64  // 所有分片占用的字节数之和
+	cursor     atomic.Uint64 // 超出上限时下一个淘汰条目的分片
+	// staleWindow 条目过期后继续保留的时间，期间可以返回旧数据并在后台刷新
+	staleWindow time.Duration
+	evictions   atomic.Int64  // 因容量不足被淘汰的条目数
+	version     atomic.Uint64 // 最近分配的版本号，每次写入都会递增
+
+	codec           Codec        // 为空时不压缩
+	minCompressSize int          // 小于该字节数的值不压缩
+	rawBytes        atomic.Int64 // 开启压缩后写入的值的原始字节数
+	storedBytes     atomic.Int64 // 开启压缩后写入的值实际保存的字节数
+}
+
+// item 缓存中保存的条目
+type item struct {
+	value      ByteView
+	compressed bool // value是否为压缩后的数据
+	created    time.Time
+	expire     time.Time    // 逻辑过期时间，零值表示永不过期
+	hits       atomic.Int64 // 命中次数，用于判断是否需要提前刷新
+	err        error        // 不为空时表示负缓存条目，记录回源返回的错误
+	version    uint64       // 写入时分配的版本号，用于compare-and-swap
+	tags       []string     // 写入时附加的标签，用于按标签失效
+}
+
+// Len 负缓存条目按错误信息的长度计入容量，标签也计入容量
+func (i *item) Len() int {
+	if i.err != nil {
+		return len(i.err.Error())
+	}
+	n := i.value.Len()
+	for _, tag := range i.tags {
+		n += len(tag)
+	}
+	return n
+}
+
+// stale 判断条目是否已过期，过期但仍在staleWindow内的条目可以作为旧数据返回
+func (i *item) stale(now time.Time) bool {
+	return !i.expire.IsZero() && now.After(i.expire)
+}
+
+// shard 淘汰策略会在Get时调整内部状态，因此读写都需要持有互斥锁
+type shard struct {
+	mu       sync.Mutex
+	policy   eviction.Policy
+	evicting bool  // 正在添加条目或缩小容量，此时被移除的条目都是因容量不足被淘汰的
+	bytes    int64 // 上次计入cache.used的字节数
+
+	tags   map[string]map[string]struct{} // 标签到key的索引
+	tagged map[string]*item               // 带标签的key对应的条目
+
+	negative      map[string]*item // 负缓存条目
+	negativeBytes int64            // 负缓存条目占用的字节数
+}
+
+// init 按配置创建分片，容量平均分配到各个分片
+func (c *cache) init() {
+	if c.newPolicy == nil {
+		c.newPolicy = policies[PolicyLRU]
+	}
+	n := c.nShards
+	if n <= 0 {
+		n = shardCount(c.maxEntries, c.maxBytes)
+	}
+	c.seed = maphash.MakeSeed()
+	c.limit.Store(c.maxBytes)
+	// 以当前时间作为版本号的起点，避免节点重启后重复使用已经发出的版本号
+	c.version.Store(uint64(time.Now().UnixNano()))
+	c.shards = make([]*shard, n)
+	for i := range c.shards {
+		s := &shard{}
+		s.policy = c.newShardPolicy(s, c.maxEntries, c.maxBytes)
+		c.shards[i] = s
+	}
+}
+
+// newShardPolicy 创建分片的淘汰策略，条目数平均分配到各个分片；字节数由所有分片共享，
+// 每个分片的上限都是总容量，超出总容量时由shrink淘汰，避免大于平均份额的值写入后被立即淘汰
+func (c *cache) newShardPolicy(s *shard, maxEntries int, maxBytes int64) eviction.Policy {
+	return c.newPolicy(shardEntries(maxEntries, len(c.shards)), maxBytes, func(key string, value eviction.Value) {
+		// 条目被淘汰、删除或过期清理时都需要清除其标签索引
+		s.unindex(key, value.(*item))
+		s.untrackNegative(key, value.(*item))
+		// 删除和过期清理也会触发回调，只统计因容量不足发生的淘汰
+		if s.evicting {
+			c.evictions.Add(1)
+		}
+	})
+}
+
+// shardCount 根据容量选择分片数，保证每个分片都有足够的容量
+func shardCount(maxEntries int, maxBytes int64) int {
+	n := defaultShards
+	for n > 1 && (maxBytes/int64(n) < minShardBytes || (maxEntries > 0 && maxEntries/n < minShardEntries)) {
+		n /= 2
+	}
+	return n
+}
+
+// shardEntries 返回每个分片的最大条目数，分片数多于条目数时每个分片至少保留1个条目，
+// 避免平均分配后变为0而不再限制条目数
+func shardEntries(maxEntries int, n int) int {
+	if maxEntries <= 0 {
+		return 0
+	}
+	return max(maxEntries/n, 1)
+}
+
+func (c *cache) shard(key string) *shard {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[maphash.String(c.seed, key)%uint64(len(c.shards))]
+}
+
+// add 添加缓存，expire为零值表示永不过期，tags为条目附加的标签
+func (c *cache) add(key string, value ByteView, expire time.Time, tags ...string) {
+	value, compressed := c.encode(value)
+	c.addItem(key, &item{value: value, compressed: compressed, created: time.Now(), expire: expire, tags: slices.Clone(tags)})
+}
+
+// addNegative 添加负缓存条目，记录回源返回的错误直到expire
+func (c *cache) addNegative(key string, err error, expire time.Time) {
+	c.addItem(key, &item{err: err, created: time.Now(), expire: expire})
+}
+
+// addItem 添加条目
+func (c *cache) addItem(key string, it *item) {
+	s := c.shard(key)
+	s.mu.Lock()
+	c.addLocked(s, key, it)
+	c.unlock(s)
+	c.shrink(key)
+}
+
+// addLocked 在持有分片锁时添加条目并分配版本号，淘汰策略中的普通条目会多保留staleWindow。
+// 超出共享的字节数上限时先淘汰本分片的其他条目，调用方释放锁后需调用shrink继续从其他分片淘汰
+func (c *cache) addLocked(s *shard, key string, it *item) {
+	it.version = c.version.Add(1)
+	expire := it.expire
+	if !expire.IsZero() && it.err == nil {
+		expire = expire.Add(c.staleWindow)
+	}
+	// 先建立索引，条目被立即淘汰时回调会清除索引
+	s.index(key, it)
+	s.trackNegative(key, it)
+	s.evicting = true
+	s.policy.AddWithExpire(key, it, expire)
+	c.syncBytes(s)
+	for c.used.Load() > c.limit.Load() && s.policy.Evict(key) {
+		c.syncBytes(s)
+	}
+	s.evicting = false
+}
+
+// syncBytes 将分片字节数的变化计入所有分片的总字节数，调用方需持有分片锁
+func (c *cache) syncBytes(s *shard) {
+	n := s.policy.Bytes()
+	c.used.Add(n - s.bytes)
+	s.bytes = n
+}
+
+// unlock 同步分片的字节数后释放分片锁，读取也可能删除过期条目，因此所有操作都通过unlock解锁
+func (c *cache) unlock(s *shard) {
+	c.syncBytes(s)
+	s.mu.Unlock()
+}
+
+// shrink 总字节数超出上限时轮流从各分片淘汰一个条目，直到不超出上限或没有可淘汰的条目，
+// keep为刚写入的key，不会被淘汰
+func (c *cache) shrink(keep string) {
+	for c.used.Load() > c.limit.Load() {
+		evicted := false
+		for range c.shards {
+			if c.used.Load() <= c.limit.Load() {
+				return
+			}
+			s := c.shards[c.cursor.Add(1)%uint64(len(c.shards))]
+			s.mu.Lock()
+			s.evicting = true
+			if s.policy.Evict(keep) {
+				evicted = true
+			}
+			s.evicting = false
+			c.unlock(s)
+		}
+		if !evicted {
+			return
+		}
+	}
+}
+
+// compareAndSwap 当前条目的版本号等于version时写入，version为0表示只在条目不存在时写入，
+// 过期和负缓存条目视为不存在，返回新条目的版本号，版本号不符时返回ErrVersionMismatch
+func (c *cache) compareAndSwap(key string, value ByteView, version uint64, expire time.Time) (uint64, error) {
+	value, compressed := c.encode(value)
+	it := &item{value: value, compressed: compressed, created: time.Now(), expire: expire}
+	s := c.shard(key)
+	s.mu.Lock()
+	defer c.shrink(key)
+	defer c.unlock(s)
+	if current := c.versionLocked(s, key, it.created); current != version {
+		return 0, ErrVersionMismatch
+	}
+	c.addLocked(s, key, it)
+	return it.version, nil
 }
 
-func (c *cache) add(key string, value ByteView) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.lru == nil {
-		c.lru = lru.New(c.maxBytes, nil)
+// currentVersion 返回未过期条目的版本号，条目不存在、已过期或为负缓存条目时返回0
+func (c *cache) currentVersion(key string) uint64 {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer c.unlock(s)
+	return c.versionLocked(s, key, time.Now())
+}
+
+// versionLocked 在持有分片锁时返回未过期条目的版本号
+func (c *cache) versionLocked(s *shard, key string, now time.Time) uint64 {
+	value, ok := s.policy.Get(key)
+	if !ok {
+		return 0
 	}
-	c.lru.Add(key, value)
+	it := value.(*item)
+	if it.err != nil || it.stale(now) {
+		return 0
+	}
+	return it.version
 }
+
+// get 获取未过期的缓存，不包括负缓存条目
 func (c *cache) get(key string) (ByteView, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if c.lru == nil {
+	it, ok := c.lookup(key)
+	if !ok || it.err != nil || it.stale(time.Now()) {
 		return ByteView{}, false
 	}
-	value, ok := c.lru.Get(key)
+	return c.view(it)
+}
+
+// lookup 获取缓存条目并记录命中，返回的条目可能已过期，由调用方决定是否使用旧数据
+func (c *cache) lookup(key string) (*item, bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	value, ok := s.policy.Get(key)
+	c.unlock(s)
 	if !ok {
-		return ByteView{}, false
+		return nil, false
+	}
+	it := value.(*item)
+	it.hits.Add(1)
+	return it, true
+}
+
+// remove 删除缓存，返回条目是否存在
+func (c *cache) remove(key string) bool {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer c.unlock(s)
+	return s.policy.Remove(key)
+}
+
+// removeExpired 清理已过期的条目，释放其占用的字节
+func (c *cache) removeExpired() int {
+	removed := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		removed += s.policy.RemoveExpired()
+		c.unlock(s)
+	}
+	return removed
+}
+
+// flush 清空所有条目，清空的条目不计入淘汰数
+func (c *cache) flush() {
+	c.sizeMu.Lock()
+	defer c.sizeMu.Unlock()
+	for _, s := range c.shards {
+		s.mu.Lock()
+		s.policy = c.newShardPolicy(s, c.maxEntries, c.maxBytes)
+		s.tags, s.tagged = nil, nil
+		s.negative, s.negativeBytes = nil, 0
+		c.unlock(s)
+	}
+}
+
+// resize 调整容量，分片数保持不变，超出新容量的条目会被淘汰
+func (c *cache) resize(maxEntries int, maxBytes int64) {
+	c.sizeMu.Lock()
+	defer c.sizeMu.Unlock()
+	c.maxEntries, c.maxBytes = maxEntries, maxBytes
+	c.limit.Store(maxBytes)
+	n := len(c.shards)
+	for _, s := range c.shards {
+		s.mu.Lock()
+		s.evicting = true
+		s.policy.Resize(shardEntries(maxEntries, n), maxBytes)
+		s.evicting = false
+		c.unlock(s)
+	}
+	c.shrink("")
+}
+
+// capacity 返回当前的容量
+func (c *cache) capacity() (int, int64) {
+	c.sizeMu.Lock()
+	defer c.sizeMu.Unlock()
+	return c.maxEntries, c.maxBytes
+}
+
+// bytes 返回当前占用的字节数
+func (c *cache) bytes() int64 {
+	var n int64
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += s.policy.Bytes()
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// len 返回条目数量
+func (c *cache) len() int {
+	n := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += s.policy.Len()
+		s.mu.Unlock()
 	}
-	return value.(ByteView), true
+	return n
 }
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestCache(nShards int, maxBytes int64) *cache {
+	c := &cache{nShards: nShards, maxBytes: maxBytes}
+	c.init()
+	return c
+}
+
+func TestShardCount(t *testing.T) {
+	tests := []struct {
+		maxEntries int
+		maxBytes   int64
+		want       int
+	}{
+		{0, 100, 1},
+		{0, 1 << 20, 16},
+		{0, 256 << 10, 4},
+		{1000, 1 << 20, 16},
+		{100, 1 << 20, 4},
+	}
+	for _, tt := range tests {
+		if got := shardCount(tt.maxEntries, tt.maxBytes); got != tt.want {
+			t.Errorf("shardCount(%d, %d) = %d, want %d", tt.maxEntries, tt.maxBytes, got, tt.want)
+		}
+	}
+}
+
+func TestCache_MoreShardsThanEntries(t *testing.T) {
+	c := &cache{nShards: 16, maxEntries: 4, maxBytes: 1 << 20}
+	c.init()
+	for i := range 100 {
+		c.add(fmt.Sprintf("k%d", i), NewByteView([]byte("v")), time.Time{})
+	}
+	// 每个分片至少保留1个条目，总数不超过分片数
+	if n := c.len(); n == 0 || n > 16 {
+		t.Errorf("expected entries to stay limited per shard, got %d", n)
+	}
+
+	c.resize(2, 1<<20)
+	if n := c.len(); n == 0 || n > 16 {
+		t.Errorf("expected entries to stay limited after resize, got %d", n)
+	}
+}
+
+func TestCache_ValueLargerThanShardShare(t *testing.T) {
+	const maxBytes = 1 << 20
+	c := newTestCache(16, maxBytes)
+	for i := range 100 {
+		c.add(fmt.Sprintf("small%d", i), NewByteView(make([]byte, 100)), time.Time{})
+	}
+	// 大于每个分片的平均份额，但小于总容量
+	big := NewByteView(make([]byte, 100<<10))
+	c.add("big", big, time.Time{})
+	if v, ok := c.get("big"); !ok || v.Len() != big.Len() {
+		t.Fatalf("expected a value larger than one shard's share to be kept, got %d bytes %v", v.Len(), ok)
+	}
+
+	for i := range 20 {
+		key := fmt.Sprintf("big%d", i)
+		c.add(key, big, time.Time{})
+		if _, ok := c.get(key); !ok {
+			t.Errorf("expected the latest value %s to be kept", key)
+		}
+		if n := c.bytes(); n > maxBytes {
+			t.Fatalf("expected total bytes within %d, got %d", maxBytes, n)
+		}
+	}
+	if n := c.evictions.Load(); n == 0 {
+		t.Error("expected older entries to be evicted across shards")
+	}
+
+	c.resize(0, 256<<10)
+	if n := c.bytes(); n > 256<<10 || n != c.used.Load() {
+		t.Errorf("expected resize to shrink the shared total, got %d (tracked %d)", n, c.used.Load())
+	}
+}
+
+func TestCache_ConcurrentAccess(t *testing.T) {
+	c := newTestCache(0, 1<<20)
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := range 1000 {
+				key := fmt.Sprintf("k%d", j%100)
+				if j%10 == i {
+					c.add(key, NewByteView([]byte(key)), time.Time{})
+				} else {
+					c.get(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for j := range 100 {
+		key := fmt.Sprintf("k%d", j)
+		if v, ok := c.get(key); ok && v.String() != key {
+			t.Errorf("expected %s, got %s", key, v.String())
+		}
+	}
+}
+
+// BenchmarkCache_Parallel 对比单锁与分片锁在并发读写下的吞吐
+func BenchmarkCache_Parallel(b *testing.B) {
+	keys := make([]string, 1024)
+	values := make([]ByteView, len(keys))
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+		values[i] = NewByteView([]byte(keys[i]))
+	}
+	for _, n := range []int{1, defaultShards} {
+		b.Run(fmt.Sprintf("shards=%d", n), func(b *testing.B) {
+			c := newTestCache(n, 16<<20)
+			for i, k := range keys {
+				c.add(k, values[i], time.Time{})
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					k := keys[i%len(keys)]
+					if i%10 == 0 {
+						c.add(k, values[i%len(keys)], time.Time{})
+					} else {
+						c.get(k)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
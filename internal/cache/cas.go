@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+	"zencache/internal/peers"
+)
+
+// ErrVersionMismatch compare-and-swap时条目的版本号与预期不符
+var ErrVersionMismatch = errors.New("VersionMismatch")
+
+// GetVersion 获取缓存及其版本号，版本号用于CompareAndSwap，key属于远程节点时从该节点获取，不使用热点缓存，
+// 版本号为0表示数据没有保存在缓存中
+func (g *Group) GetVersion(ctx context.Context, key string) (ByteView, uint64, error) {
+	if key == "" {
+		return ByteView{}, 0, ErrKeyIsNil
+	}
+	peer, ok := g.pickPeer(key)
+	if !ok {
+		return g.GetVersionLocal(ctx, key)
+	}
+	versioner, ok := peer.(peers.PeerVersioner)
+	if !ok {
+		return ByteView{}, 0, ErrPeerUnsupported
+	}
+	g.stats.gets.Add(1)
+	g.stats.misses.Add(1)
+	bs, version, err := versioner.GetVersion(ctx, g.name, key)
+	g.stats.recordPeerLoad(err)
+	if err != nil {
+		return ByteView{}, 0, err
+	}
+	return NewByteView(bs), version, nil
+}
+
+// GetVersionLocal 与GetVersion相同，但只从本地缓存或回源获取，用于处理其他节点转发来的请求
+func (g *Group) GetVersionLocal(ctx context.Context, key string) (ByteView, uint64, error) {
+	if key == "" {
+		return ByteView{}, 0, ErrKeyIsNil
+	}
+	g.stats.gets.Add(1)
+	if value, version, ok := g.lookupVersion(key); ok {
+		g.stats.hits.Add(1)
+		return value, version, nil
+	}
+	g.stats.misses.Add(1)
+	value, err := g.loadLocally(ctx, key)
+	if err != nil {
+		return ByteView{}, 0, err
+	}
+	// 回源的数据写入缓存后可能已被其他请求更新，以缓存中的条目为准
+	if cached, version, ok := g.lookupVersion(key); ok {
+		return cached, version, nil
+	}
+	return value, 0, nil
+}
+
+// lookupVersion 获取未过期的缓存及其版本号，不包括负缓存条目
+func (g *Group) lookupVersion(key string) (ByteView, uint64, bool) {
+	it, ok := g.cache.lookup(key)
+	if !ok || it.err != nil || it.stale(time.Now()) {
+		return ByteView{}, 0, false
+	}
+	value, ok := g.cache.view(it)
+	return value, it.version, ok
+}
+
+// CompareAndSwap 当前条目的版本号等于version时写入value并返回新的版本号，否则返回ErrVersionMismatch，
+// version为0表示只在key不在缓存中时写入。key属于远程节点时由该节点比较和写入，
+// 设置了Setter时写入缓存成功后再写入数据源，写入数据源失败时删除缓存
+func (g *Group) CompareAndSwap(key string, value ByteView, version uint64, ttl time.Duration) (uint64, error) {
+	if key == "" {
+		return 0, ErrKeyIsNil
+	}
+	peer, ok := g.pickPeer(key)
+	if !ok {
+		return g.CompareAndSwapLocal(key, value, version, ttl)
+	}
+	versioner, ok := peer.(peers.PeerVersioner)
+	if !ok {
+		return 0, ErrPeerUnsupported
+	}
+	// key属于远程节点，删除本地可能存在的旧数据
+	g.cache.remove(key)
+	g.hotCache.remove(key)
+	return versioner.CompareAndSwap(g.name, key, value.ByteSlices(), version, ttl)
+}
+
+// CompareAndSwapLocal 只在本地缓存比较和写入，不转发给远程节点，用于处理其他节点转发来的请求，
+// 设置了Setter时写入缓存成功后再写入数据源
+func (g *Group) CompareAndSwapLocal(key string, value ByteView, version uint64, ttl time.Duration) (uint64, error) {
+	if key == "" {
+		return 0, ErrKeyIsNil
+	}
+	newVersion, err := g.cache.compareAndSwap(key, value, version, g.expireAt(ttl))
+	if err != nil {
+		return 0, err
+	}
+	if err := g.storeSetAfterWrite(key, value); err != nil {
+		return 0, err
+	}
+	return newVersion, nil
+}
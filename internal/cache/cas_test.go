@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGroup_CompareAndSwap(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("cas", nil, 1<<10, WithSweepInterval(0))
+	g := e.GetGroup("cas")
+
+	if _, _, err := g.GetVersion(context.Background(), "k"); err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+	v1, err := g.CompareAndSwap("k", NewByteView([]byte("a")), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.CompareAndSwap("k", NewByteView([]byte("b")), 0, 0); err != ErrVersionMismatch {
+		t.Errorf("expected ErrVersionMismatch creating an existing key, got %v", err)
+	}
+	v2, err := g.CompareAndSwap("k", NewByteView([]byte("c")), v1, 0)
+	if err != nil || v2 <= v1 {
+		t.Fatalf("expected a newer version than %d, got %d %v", v1, v2, err)
+	}
+	if _, err := g.CompareAndSwap("k", NewByteView([]byte("d")), v1, 0); err != ErrVersionMismatch {
+		t.Errorf("expected ErrVersionMismatch with an old version, got %v", err)
+	}
+
+	// 普通写入同样会更新版本号
+	g.Add("k", NewByteView([]byte("e")))
+	v, version, err := g.GetVersion(context.Background(), "k")
+	if err != nil || v.String() != "e" || version <= v2 {
+		t.Errorf("expected e at a version newer than %d, got %q at %d %v", v2, v.String(), version, err)
+	}
+}
+
+func TestGroup_CompareAndSwapExpired(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("cas-expired", nil, 1<<10, WithSweepInterval(0))
+	g := e.GetGroup("cas-expired")
+
+	version, _ := g.CompareAndSwap("k", NewByteView([]byte("a")), 0, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, err := g.CompareAndSwap("k", NewByteView([]byte("b")), version, 0); err != ErrVersionMismatch {
+		t.Errorf("expected ErrVersionMismatch for an expired entry, got %v", err)
+	}
+	if _, err := g.CompareAndSwap("k", NewByteView([]byte("b")), 0, 0); err != nil {
+		t.Errorf("expected an expired entry to be treated as absent, got %v", err)
+	}
+}
+
+func TestGroup_GetVersionLoaded(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("cas-load", GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), 1<<10, WithSweepInterval(0))
+	g := e.GetGroup("cas-load")
+
+	v, version, err := g.GetVersion(context.Background(), "k")
+	if err != nil || v.String() != "k" || version == 0 {
+		t.Fatalf("expected loaded value with a version, got %q at %d %v", v.String(), version, err)
+	}
+	if _, err := g.CompareAndSwap("k", NewByteView([]byte("x")), version, 0); err != nil {
+		t.Errorf("expected swap with the loaded version to succeed, got %v", err)
+	}
+}
+
+func TestGroup_CompareAndSwapConcurrent(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("cas-counter", nil, 1<<10, WithSweepInterval(0))
+	g := e.GetGroup("cas-counter")
+	g.Add("n", NewByteView([]byte("0")))
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				for {
+					v, version, err := g.GetVersion(context.Background(), "n")
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					n, _ := strconv.Atoi(v.String())
+					_, err = g.CompareAndSwap("n", NewByteView([]byte(strconv.Itoa(n+1))), version, 0)
+					if err == nil {
+						break
+					}
+					if !errors.Is(err, ErrVersionMismatch) {
+						t.Error(err)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := g.Get("n"); v.String() != "400" {
+		t.Errorf("expected no lost updates, got %s", v.String())
+	}
+}
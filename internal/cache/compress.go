@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// Codec 压缩算法，实现需要保证并发安全
+type Codec interface {
+	Compress(src []byte) ([]byte, error)
+	Decompress(src []byte) ([]byte, error)
+}
+
+// 内置的压缩算法名称
+const (
+	CodecFlate = "flate"
+	CodecGzip  = "gzip"
+)
+
+// CodecByName 根据名称和压缩级别创建内置的压缩算法，用于按配置创建。
+// 配置中未填写的级别为0，对应flate.NoCompression，因此0按默认级别处理，不需要压缩时不配置压缩算法即可
+func CodecByName(name string, level int) (Codec, error) {
+	if level == flate.NoCompression {
+		level = flate.DefaultCompression
+	}
+	switch name {
+	case CodecFlate:
+		return NewFlateCodec(level)
+	case CodecGzip:
+		return NewGzipCodec(level)
+	}
+	return nil, fmt.Errorf("unknown codec %q", name)
+}
+
+// WithCompression 对不小于minSize字节的值使用codec压缩后保存，按压缩后的大小计入容量，读取时解压，
+// 压缩后没有变小的值按原样保存
+func WithCompression(codec Codec, minSize int) GroupOption {
+	return func(g *Group) {
+		g.cache.codec = codec
+		g.cache.minCompressSize = minSize
+	}
+}
+
+// flateCodec 使用compress/flate压缩，复用压缩器以减少内存分配
+type flateCodec struct {
+	level   int
+	writers sync.Pool
+}
+
+// NewFlateCodec 创建flate压缩算法，level的取值与compress/flate相同
+func NewFlateCodec(level int) (Codec, error) {
+	if _, err := flate.NewWriter(io.Discard, level); err != nil {
+		return nil, err
+	}
+	return &flateCodec{level: level}, nil
+}
+
+func (f *flateCodec) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, _ := f.writers.Get().(*flate.Writer)
+	if w == nil {
+		w, _ = flate.NewWriter(&buf, f.level)
+	} else {
+		w.Reset(&buf)
+	}
+	defer f.writers.Put(w)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *flateCodec) Decompress(src []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(src))
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// gzipCodec 使用compress/gzip压缩，复用压缩器以减少内存分配
+type gzipCodec struct {
+	level   int
+	writers sync.Pool
+}
+
+// NewGzipCodec 创建gzip压缩算法，level的取值与compress/gzip相同
+func NewGzipCodec(level int) (Codec, error) {
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		return nil, err
+	}
+	return &gzipCodec{level: level}, nil
+}
+
+func (g *gzipCodec) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, _ := g.writers.Get().(*gzip.Writer)
+	if w == nil {
+		w, _ = gzip.NewWriterLevel(&buf, g.level)
+	} else {
+		w.Reset(&buf)
+	}
+	defer g.writers.Put(w)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g *gzipCodec) Decompress(src []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// encode 按配置压缩值，返回保存的值和是否压缩
+func (c *cache) encode(value ByteView) (ByteView, bool) {
+	if c.codec == nil {
+		return value, false
+	}
+	c.rawBytes.Add(int64(value.Len()))
+	if value.Len() >= c.minCompressSize {
+		compressed, err := c.codec.Compress(value.bytes)
+		if err == nil && len(compressed) < value.Len() {
+			c.storedBytes.Add(int64(len(compressed)))
+			return ByteView{compressed}, true
+		}
+	}
+	c.storedBytes.Add(int64(value.Len()))
+	return value, false
+}
+
+// view 返回条目的值，压缩保存的值会被解压
+func (c *cache) view(it *item) (ByteView, bool) {
+	if !it.compressed {
+		return it.value, true
+	}
+	bs, err := c.codec.Decompress(it.value.bytes)
+	if err != nil {
+		log.Printf("decompress: %v", err)
+		return ByteView{}, false
+	}
+	return ByteView{bs}, true
+}
+
+// compressionRatio 返回开启压缩后写入的值的原始大小与保存大小之比，未开启压缩时为0
+func (c *cache) compressionRatio() float64 {
+	stored := c.storedBytes.Load()
+	if stored == 0 {
+		return 0
+	}
+	return float64(c.rawBytes.Load()) / float64(stored)
+}
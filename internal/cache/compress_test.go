@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"bytes"
+	"compress/flate"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGroup_Compression(t *testing.T) {
+	for _, name := range []string{CodecFlate, CodecGzip} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := CodecByName(name, flate.BestSpeed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := NewEngine()
+			e.AddGroup("compress-"+name, nil, 1<<20, WithSweepInterval(0), WithCompression(codec, 64))
+			g := e.GetGroup("compress-" + name)
+
+			large := strings.Repeat("zencache ", 1000)
+			if err := g.Add("large", NewByteView([]byte(large))); err != nil {
+				t.Fatal(err)
+			}
+			if err := g.Add("small", NewByteView([]byte("tiny"))); err != nil {
+				t.Fatal(err)
+			}
+
+			if v, err := g.Get("large"); err != nil || v.String() != large {
+				t.Fatalf("expected large value to round-trip, got %d bytes, %v", v.Len(), err)
+			}
+			if v, err := g.Get("small"); err != nil || v.String() != "tiny" {
+				t.Fatalf("expected small value to round-trip, got %q, %v", v, err)
+			}
+
+			s := g.Stats()
+			if s.Bytes >= int64(len(large)) {
+				t.Errorf("expected compressed value to use less than %d bytes, got %d", len(large), s.Bytes)
+			}
+			if s.CompressionRatio <= 1 {
+				t.Errorf("expected compression ratio above 1, got %f", s.CompressionRatio)
+			}
+		})
+	}
+}
+
+func TestCache_CompressionThreshold(t *testing.T) {
+	codec, _ := NewFlateCodec(flate.DefaultCompression)
+	c := newTestCache(1, 1<<20)
+	c.codec = codec
+	c.minCompressSize = 1 << 10
+
+	small := bytes.Repeat([]byte{'a'}, 512)
+	c.add("small", NewByteView(small), time.Time{})
+	if it, _ := c.lookup("small"); it.compressed {
+		t.Error("expected value below threshold to be stored uncompressed")
+	}
+
+	// 无法压缩的数据按原样保存
+	random := []byte("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	c.minCompressSize = 0
+	c.add("random", NewByteView(random), time.Time{})
+	if it, _ := c.lookup("random"); it.compressed {
+		t.Error("expected incompressible value to be stored uncompressed")
+	}
+	if v, ok := c.get("random"); !ok || !bytes.Equal(v.ByteSlices(), random) {
+		t.Errorf("expected %q, got %q", random, v)
+	}
+}
+
+func TestCodecByName_Unknown(t *testing.T) {
+	if _, err := CodecByName("zstd", 0); err == nil {
+		t.Error("expected error for unknown codec")
+	}
+	if _, err := NewFlateCodec(42); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
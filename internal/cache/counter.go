@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+	"zencache/internal/peers"
+)
+
+// ErrInvalidCounter 缓存中的值不是int64范围内的十进制整数，或增减后超出int64范围
+var ErrInvalidCounter = errors.New("InvalidCounter")
+
+// Incr 将key对应的计数器原子地增加delta并返回新值，key属于远程节点时由该节点执行。
+// 计数器以十进制字符串保存为普通的缓存条目，与其他条目一样会过期和被淘汰；
+// key不在缓存中时写入initial并返回initial，ttl只在创建计数器时使用，<=0时使用Group的默认过期时间
+func (g *Group) Incr(key string, delta int64, initial int64, ttl time.Duration) (int64, error) {
+	if key == "" {
+		return 0, ErrKeyIsNil
+	}
+	peer, ok := g.pickPeer(key)
+	if !ok {
+		return g.IncrLocal(key, delta, initial, ttl)
+	}
+	incrementer, ok := peer.(peers.PeerIncrementer)
+	if !ok {
+		return 0, ErrPeerUnsupported
+	}
+	// key属于远程节点，删除本地可能存在的旧数据
+	g.cache.remove(key)
+	g.hotCache.remove(key)
+	return incrementer.Incr(g.name, key, delta, initial, ttl)
+}
+
+// Decr 将key对应的计数器原子地减少delta并返回新值，其他与Incr相同，
+// delta为math.MinInt64时取反会溢出，返回ErrInvalidCounter
+func (g *Group) Decr(key string, delta int64, initial int64, ttl time.Duration) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, ErrInvalidCounter
+	}
+	return g.Incr(key, -delta, initial, ttl)
+}
+
+// IncrLocal 只在本地缓存增加计数器，不转发给远程节点，用于处理其他节点转发来的请求，
+// 设置了Setter时写入缓存成功后再写入数据源
+func (g *Group) IncrLocal(key string, delta int64, initial int64, ttl time.Duration) (int64, error) {
+	if key == "" {
+		return 0, ErrKeyIsNil
+	}
+	n, err := g.cache.incr(key, delta, initial, g.expireAt(ttl))
+	if err != nil {
+		return 0, err
+	}
+	if err := g.storeSetAfterWrite(key, counterValue(n)); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// counterValue 将计数器的值转换为保存的十进制字符串
+func counterValue(n int64) ByteView {
+	return ByteView{strconv.AppendInt(nil, n, 10)}
+}
+
+// incr 在分片锁内读取、增加并写回计数器，过期和负缓存条目视为不存在，已有计数器的过期时间保持不变
+func (c *cache) incr(key string, delta int64, initial int64, expire time.Time) (int64, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer c.shrink(key)
+	defer c.unlock(s)
+	now := time.Now()
+	n := initial
+	if value, ok := s.policy.Get(key); ok {
+		if it := value.(*item); it.err == nil && !it.stale(now) {
+			view, ok := c.view(it)
+			if !ok {
+				return 0, ErrInvalidCounter
+			}
+			current, err := strconv.ParseInt(view.String(), 10, 64)
+			if err != nil {
+				return 0, ErrInvalidCounter
+			}
+			n = current + delta
+			// 加上正数后变小或加上负数后变大说明溢出
+			if (delta > 0 && n < current) || (delta < 0 && n > current) {
+				return 0, ErrInvalidCounter
+			}
+			expire = it.expire
+		}
+	}
+	value, compressed := c.encode(counterValue(n))
+	c.addLocked(s, key, &item{value: value, compressed: compressed, created: now, expire: expire})
+	return n, nil
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+	"zencache/internal/peers"
+)
+
+func TestGroup_IncrDecr(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("counter", nil, 1<<10, WithSweepInterval(0))
+	g := e.GetGroup("counter")
+
+	if n, err := g.Incr("n", 5, 10, 0); err != nil || n != 10 {
+		t.Fatalf("expected a missing counter to start at 10, got %d %v", n, err)
+	}
+	if n, err := g.Incr("n", 5, 10, 0); err != nil || n != 15 {
+		t.Errorf("expected 15, got %d %v", n, err)
+	}
+	if n, err := g.Decr("n", 20, 0, 0); err != nil || n != -5 {
+		t.Errorf("expected -5, got %d %v", n, err)
+	}
+	if v, err := g.Get("n"); err != nil || v.String() != "-5" {
+		t.Errorf("expected counter stored as -5, got %q %v", v.String(), err)
+	}
+
+	g.Add("s", NewByteView([]byte("abc")))
+	if _, err := g.Incr("s", 1, 0, 0); err != ErrInvalidCounter {
+		t.Errorf("expected ErrInvalidCounter for a non-numeric value, got %v", err)
+	}
+	g.Add("max", counterValue(math.MaxInt64))
+	if _, err := g.Incr("max", 1, 0, 0); err != ErrInvalidCounter {
+		t.Errorf("expected ErrInvalidCounter on overflow, got %v", err)
+	}
+	// math.MinInt64取反会溢出
+	if _, err := g.Decr("n", math.MinInt64, 0, 0); err != ErrInvalidCounter {
+		t.Errorf("expected ErrInvalidCounter for delta MinInt64, got %v", err)
+	}
+	if v, _ := g.Get("n"); v.String() != "-5" {
+		t.Errorf("expected rejected decrement to leave the counter unchanged, got %q", v.String())
+	}
+}
+
+// typedNilPicker 返回类型不为空、值为空的节点和false，key由本地处理
+type typedNilPicker struct{}
+
+func (typedNilPicker) PickPeer(key string) (peers.PeerGetter, bool) {
+	return (*testPeer)(nil), false
+}
+
+func TestGroup_IncrStoreErrorWithTypedNilPeer(t *testing.T) {
+	store := newTestStore()
+	e := NewEngine()
+	e.AddGroup("counter-store", nil, 1<<10, WithSweepInterval(0),
+		WithWriteThrough(store, store), WithPeersPicker(typedNilPicker{}))
+	g := e.GetGroup("counter-store")
+
+	store.fail = 1
+	if _, err := g.Incr("n", 1, 1, 0); err == nil {
+		t.Fatal("expected store error to be returned")
+	}
+	// 写入数据源失败时删除本地缓存，而不是把空的节点当作远程节点
+	if _, ok := g.cache.get("n"); ok {
+		t.Error("expected the local counter to be removed after the store failed")
+	}
+}
+
+func TestGroup_IncrTTL(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("counter-ttl", nil, 1<<10, WithSweepInterval(0))
+	g := e.GetGroup("counter-ttl")
+
+	g.Incr("n", 1, 1, 50*time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	// 增加计数器不会延长过期时间
+	g.Incr("n", 1, 1, 50*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	if n, err := g.Incr("n", 1, 1, 0); err != nil || n != 1 {
+		t.Errorf("expected the counter to restart after its window, got %d %v", n, err)
+	}
+}
+
+func TestGroup_IncrConcurrent(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("counter-concurrent", nil, 1<<10, WithSweepInterval(0))
+	g := e.GetGroup("counter-concurrent")
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				g.Incr("n", 1, 1, 0)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := g.Get("n"); v.String() != "800" {
+		t.Errorf("expected no lost updates, got %s", v.String())
+	}
+}
@@ -1,14 +1,32 @@
 package cache
 
 import (
+	"errors"
+	"log"
+	"slices"
 	"sync"
-	"zencache/internal/lru"
+	"time"
+	"zencache/internal/config"
+	"zencache/internal/peers"
+)
+
+var (
+	// ErrGroupExists 同名的Group已存在
+	ErrGroupExists = errors.New("GroupExists")
+	// ErrGroupNotFound Group不存在
+	ErrGroupNotFound = errors.New("GroupNotFound")
 )
 
 // 外部交互使用
 type Engine struct {
 	groups map[string]*Group
 	mutex  sync.RWMutex
+	conf   *config.CacheConfig // 为空时不应用配置文件中的Group配置
+	picker peers.PeersPicker   // 新建的Group默认使用的节点选择器，为空时以单机模式运行
+
+	maxTotalBytes int64 // 所有Group的总容量上限，0表示不限制
+	stopRebalance chan struct{}
+	closeOnce     sync.Once
 }
 
 func NewEngine() *Engine {
@@ -17,22 +35,245 @@ func NewEngine() *Engine {
 	}
 }
 
+// NewEngineWithConfig 创建Engine，新建的Group会应用配置中的默认值和按名称覆盖的配置，
+// 配置了总容量上限时在后台定期重新分配各Group的容量
+func NewEngineWithConfig(conf *config.CacheConfig) *Engine {
+	e := NewEngine()
+	e.conf = conf
+	e.maxTotalBytes = conf.MaxTotalBytes
+	if e.maxTotalBytes > 0 {
+		interval := time.Duration(conf.RebalanceIntervalMs) * time.Millisecond
+		if interval <= 0 {
+			interval = defaultRebalanceInterval
+		}
+		e.stopRebalance = make(chan struct{})
+		go e.rebalanceLoop(interval)
+	}
+	return e
+}
+
+// RegisterPicker 设置默认的节点选择器，之后新建的Group会自动使用
+func (e *Engine) RegisterPicker(picker peers.PeersPicker) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.picker = picker
+}
+
 func (e *Engine) GetGroup(name string) *Group {
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
 	return e.groups[name]
 }
-func (e *Engine) AddGroup(name string, getter Getter, maxBytes int64) {
+
+// AddGroup 创建Group，同名的Group已存在时返回ErrGroupExists，需要替换时使用ReplaceGroup，
+// 配置了总容量上限时maxBytes为该Group最多分到的容量，剩余容量不足时返回ErrMemoryBudgetExceeded
+func (e *Engine) AddGroup(name string, getter Getter, maxBytes int64, opts ...GroupOption) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
+	if _, ok := e.groups[name]; ok {
+		return ErrGroupExists
+	}
+	_, err := e.addGroupLocked(name, getter, maxBytes, opts...)
+	return err
+}
+
+// ReplaceGroup 创建Group，替换并关闭同名的Group，旧Group中的数据会被丢弃
+func (e *Engine) ReplaceGroup(name string, getter Getter, maxBytes int64, opts ...GroupOption) (*Group, error) {
+	e.mutex.Lock()
+	old := e.groups[name]
+	g, err := e.addGroupLocked(name, getter, maxBytes, opts...)
+	e.mutex.Unlock()
+	// 关闭时需要等待write-behind队列写完，释放锁后再关闭，避免阻塞其他Group的访问
+	if err == nil && old != nil {
+		old.Close()
+	}
+	return g, err
+}
+
+// GetOrAddGroup 获取Group，不存在时创建，created表示是否为新建的Group，
+// 未在配置中声明的Group数量达到配置的上限时返回ErrTooManyGroups
+func (e *Engine) GetOrAddGroup(name string, getter Getter, maxBytes int64, opts ...GroupOption) (g *Group, created bool, err error) {
+	if g := e.GetGroup(name); g != nil {
+		return g, false, nil
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if g, ok := e.groups[name]; ok {
+		return g, false, nil
+	}
+	if err := e.admitUnconfiguredLocked(name); err != nil {
+		return nil, false, err
+	}
+	g, err = e.addGroupLocked(name, getter, maxBytes, opts...)
+	return g, err == nil, err
+}
+
+// addGroupLocked 创建Group并替换同名的Group，调用方需持有写锁，并在释放锁后关闭被替换的Group
+func (e *Engine) addGroupLocked(name string, getter Getter, maxBytes int64, opts ...GroupOption) (*Group, error) {
 	g := &Group{
 		cache: &cache{
-			lru:      lru.New(maxBytes, nil),
-			mu:       sync.RWMutex{},
 			maxBytes: maxBytes,
 		},
-		getter: getter,
-		name:   name,
+		getter:        AdaptGetter(getter),
+		name:          name,
+		peersPicker:   e.picker,
+		sweepInterval: defaultSweepInterval,
+		maxRefreshes:  defaultMaxRefreshes,
+	}
+	// 配置文件中的设置优先级低于调用方显式传入的选项
+	for _, opt := range append(e.configOptions(name), opts...) {
+		opt(g)
+	}
+	if bg, ok := getter.(BatchGetter); ok && g.batchGetter == nil {
+		g.batchGetter = bg
+	}
+	g.budget.requested = maxBytes
+	old := e.groups[name]
+	if err := e.admitLocked(g, old); err != nil {
+		return nil, err
+	}
+	g.cache.init()
+	g.refreshSem = make(chan struct{}, max(g.maxRefreshes, 1))
+	if g.hotCache != nil {
+		g.hotCache.cache.init()
+	}
+	if g.writeQueue != nil {
+		go g.writeQueue.run()
+	}
+	if g.sweepInterval > 0 {
+		g.stopSweep = make(chan struct{})
+		go g.sweep()
 	}
 	e.groups[name] = g
+	e.rebalanceLocked(false)
+	return g, nil
+}
+
+// configOptions 将配置转换为GroupOption
+func (e *Engine) configOptions(name string) []GroupOption {
+	if e.conf == nil {
+		return nil
+	}
+	policy, ttlMs, maxEntries := e.conf.Policy, e.conf.TTLMs, e.conf.MaxEntries
+	var opts []GroupOption
+	if gc, ok := e.conf.Groups[name]; ok {
+		if gc.Policy != "" {
+			policy = gc.Policy
+		}
+		if gc.MaxEntries != 0 {
+			maxEntries = gc.MaxEntries
+		}
+		if gc.TTLMs != 0 {
+			ttlMs = gc.TTLMs
+		}
+		if gc.StaleWhileRevalidateMs > 0 {
+			opts = append(opts, WithStaleWhileRevalidate(time.Duration(gc.StaleWhileRevalidateMs)*time.Millisecond))
+		}
+		if gc.RefreshAheadMs > 0 {
+			opts = append(opts, WithRefreshAhead(time.Duration(gc.RefreshAheadMs)*time.Millisecond, gc.RefreshAheadMinHits))
+		}
+		if gc.MaxConcurrentRefreshes > 0 {
+			opts = append(opts, WithMaxConcurrentRefreshes(gc.MaxConcurrentRefreshes))
+		}
+		if gc.NotFoundTTLMs > 0 || gc.ErrorTTLMs > 0 {
+			opts = append(opts, WithNegativeCache(time.Duration(gc.NotFoundTTLMs)*time.Millisecond,
+				time.Duration(gc.ErrorTTLMs)*time.Millisecond))
+		}
+		if cc := gc.Compression; cc != nil {
+			if codec, err := CodecByName(cc.Codec, cc.Level); err != nil {
+				log.Printf("group %s: %v, compression disabled", name, err)
+			} else {
+				opts = append(opts, WithCompression(codec, cc.MinBytes))
+			}
+		}
+		if gc.MinBytes > 0 || gc.Weight > 0 {
+			opts = append(opts, WithBudget(gc.MinBytes, gc.Weight))
+		}
+		if hc := gc.HotCache; hc != nil && hc.MaxBytes > 0 {
+			opts = append(opts, WithHotCache(hc.MaxBytes, time.Duration(hc.TTLMs)*time.Millisecond, hc.SampleRate))
+		}
+	}
+	opts = append(opts, WithMaxEntries(maxEntries))
+	if factory, err := PolicyByName(policy); err != nil {
+		log.Printf("group %s: %v, fallback to %s", name, err, PolicyLRU)
+	} else {
+		opts = append(opts, WithPolicy(factory))
+	}
+	if ttlMs > 0 {
+		opts = append(opts, WithDefaultTTL(time.Duration(ttlMs)*time.Millisecond))
+	}
+	return opts
+}
+
+// RemoveGroup 删除并关闭Group，其容量会分配给其他Group
+func (e *Engine) RemoveGroup(name string) error {
+	e.mutex.Lock()
+	g, ok := e.groups[name]
+	if ok {
+		delete(e.groups, name)
+		e.rebalanceLocked(false)
+	}
+	e.mutex.Unlock()
+	if !ok {
+		return ErrGroupNotFound
+	}
+	g.Close()
+	return nil
+}
+
+// ListGroups 返回所有Group的名称，按名称排序
+func (e *Engine) ListGroups() []string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	names := make([]string, 0, len(e.groups))
+	for name := range e.groups {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Flush 清空Group在本节点上的缓存
+func (e *Engine) Flush(name string) error {
+	g := e.GetGroup(name)
+	if g == nil {
+		return ErrGroupNotFound
+	}
+	g.Flush()
+	return nil
+}
+
+// Resize 调整Group在本节点上的字节数上限，配置了总容量上限时调整的是该Group最多分到的容量
+func (e *Engine) Resize(name string, maxBytes int64) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	g, ok := e.groups[name]
+	if !ok {
+		return ErrGroupNotFound
+	}
+	g.budget.requested = maxBytes
+	if e.maxTotalBytes > 0 {
+		e.rebalanceLocked(false)
+	} else {
+		g.Resize(maxBytes)
+	}
+	return nil
+}
+
+// Close 停止所有Group和Engine的后台任务
+func (e *Engine) Close() {
+	e.closeOnce.Do(func() {
+		if e.stopRebalance != nil {
+			close(e.stopRebalance)
+		}
+	})
+	e.mutex.RLock()
+	groups := make([]*Group, 0, len(e.groups))
+	for _, g := range e.groups {
+		groups = append(groups, g)
+	}
+	e.mutex.RUnlock()
+	for _, g := range groups {
+		g.Close()
+	}
 }
@@ -1,7 +1,10 @@
 package cache
 
 import (
+	"strings"
 	"testing"
+	"zencache/internal/config"
+	"zencache/internal/tinylfu"
 )
 
 // MockGetter is a simple mock implementation of the Getter interface.
@@ -26,13 +29,64 @@ func TestEngine_AddGroup(t *testing.T) {
 
 	// Add another group with the same name
 	newGetter := MockGetter{}
-	e.AddGroup(name, newGetter, maxBytes)
-	g2 := e.GetGroup(name)
-	if g2 == g1 {
+	if err := e.AddGroup(name, newGetter, maxBytes); err != ErrGroupExists {
+		t.Errorf("expected ErrGroupExists, got %v", err)
+	}
+	if e.GetGroup(name) != g1 {
+		t.Error("expected duplicate AddGroup to keep the existing group")
+	}
+
+	// Replace explicitly
+	g2, err := e.ReplaceGroup(name, newGetter, maxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g2 == g1 || e.GetGroup(name) != g2 {
 		t.Error("expected new group to replace the old one, but they are the same")
 	}
 }
 
+func TestEngine_Lifecycle(t *testing.T) {
+	e := NewEngine()
+	for _, name := range []string{"b", "a"} {
+		if err := e.AddGroup(name, nil, 1<<10, WithSweepInterval(0), WithShards(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if names := e.ListGroups(); len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("expected sorted group names, got %v", names)
+	}
+
+	g := e.GetGroup("a")
+	for _, k := range []string{"k1", "k2", "k3"} {
+		g.Add(k, NewByteView(make([]byte, 10)))
+	}
+	if err := e.Resize("a", 20); err != nil {
+		t.Fatal(err)
+	}
+	if s := g.Stats(); s.Items != 1 || s.MaxBytes != 20 || s.Evictions != 2 {
+		t.Errorf("expected shrinking to evict down to one item, got %+v", s)
+	}
+	if err := e.Flush("a"); err != nil {
+		t.Fatal(err)
+	}
+	if s := g.Stats(); s.Items != 0 || s.Bytes != 0 || s.MaxBytes != 20 {
+		t.Errorf("expected flush to empty the group and keep its size, got %+v", s)
+	}
+
+	if err := e.RemoveGroup("a"); err != nil {
+		t.Fatal(err)
+	}
+	if e.GetGroup("a") != nil {
+		t.Error("expected removed group to be gone")
+	}
+	for _, err := range []error{e.RemoveGroup("a"), e.Flush("a"), e.Resize("a", 1)} {
+		if err != ErrGroupNotFound {
+			t.Errorf("expected ErrGroupNotFound, got %v", err)
+		}
+	}
+}
+
 func TestEngine_GetGroup(t *testing.T) {
 	e := NewEngine()
 	name := "testGroup"
@@ -53,4 +107,64 @@ func TestEngine_GetGroup(t *testing.T) {
 	}
 }
 
+func TestEngine_ConfigPolicy(t *testing.T) {
+	e := NewEngineWithConfig(&config.CacheConfig{
+		Policy: "lru",
+		Groups: map[string]config.GroupConfig{
+			"scan": {Policy: "tinylfu"},
+		},
+	})
+	e.AddGroup("scan", nil, 100, WithSweepInterval(0))
+	g := e.GetGroup("scan")
+	g.Add("k", NewByteView([]byte("v")))
+
+	if _, ok := g.cache.shards[0].policy.(*tinylfu.Cache); !ok {
+		t.Errorf("expected tinylfu policy from config, got %T", g.cache.shards[0].policy)
+	}
+}
+
+func TestEngine_ConfigCompressionDefaultLevel(t *testing.T) {
+	e := NewEngineWithConfig(&config.CacheConfig{
+		Groups: map[string]config.GroupConfig{
+			"json": {Compression: &config.CompressionConfig{Codec: "gzip"}},
+		},
+	})
+	e.AddGroup("json", nil, 1<<20, WithSweepInterval(0))
+	g := e.GetGroup("json")
+	g.Add("k", NewByteView([]byte(strings.Repeat(`{"id":1,"name":"zencache"},`, 120))))
+
+	// 未填写级别时使用默认级别，而不是flate.NoCompression
+	if ratio := g.Stats().CompressionRatio; ratio <= 2 {
+		t.Errorf("expected values to be compressed without a level, got ratio %.2f", ratio)
+	}
+}
+
+func TestEngine_ConfigMaxEntries(t *testing.T) {
+	e := NewEngineWithConfig(&config.CacheConfig{MaxEntries: 2})
+	e.AddGroup("tiny", nil, 1<<20, WithSweepInterval(0))
+	g := e.GetGroup("tiny")
+	for _, k := range []string{"k1", "k2", "k3"} {
+		g.Add(k, NewByteView([]byte("v")))
+	}
+
+	if n := g.cache.len(); n != 2 {
+		t.Errorf("expected max entries from config to cap group at 2, got %d", n)
+	}
+}
+
+func TestEngine_RegisterPicker(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("before", nil, 100, WithSweepInterval(0))
+	picker := testPicker{peer: &testPeer{}}
+	e.RegisterPicker(picker)
+	e.AddGroup("after", nil, 100, WithSweepInterval(0))
+
+	if e.GetGroup("before").peersPicker != nil {
+		t.Error("groups created before RegisterPicker should stay standalone")
+	}
+	if e.GetGroup("after").peersPicker != picker {
+		t.Error("expected new group to inherit the engine picker")
+	}
+}
+
 // Additional tests for concurrency can be added following a similar pattern
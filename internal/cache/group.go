@@ -1,8 +1,13 @@
 package cache
 
 import (
+	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
 	"zencache/internal/peers"
+	"zencache/internal/singleflight"
 )
 
 type GetterFunc func(string) ([]byte, error)
@@ -14,58 +19,353 @@ func (f GetterFunc) Get(key string) ([]byte, error) {
 	return f(key)
 }
 
+// ContextGetter 支持通过ctx控制超时和取消的Getter
+type ContextGetter interface {
+	GetContext(ctx context.Context, key string) ([]byte, error)
+}
+
+type ContextGetterFunc func(context.Context, string) ([]byte, error)
+
+func (f ContextGetterFunc) GetContext(ctx context.Context, key string) ([]byte, error) {
+	return f(ctx, key)
+}
+
+// AdaptGetter 将Getter转换为ContextGetter，已实现ContextGetter时直接使用，否则忽略ctx
+func AdaptGetter(getter Getter) ContextGetter {
+	if getter == nil {
+		return nil
+	}
+	if cg, ok := getter.(ContextGetter); ok {
+		return cg
+	}
+	return ContextGetterFunc(func(_ context.Context, key string) ([]byte, error) {
+		return getter.Get(key)
+	})
+}
+
 var (
 	ErrKeyNotFound = errors.New("KeyNotFound")
 	ErrKeyIsNil    = errors.New("KeyIsNil")
+	// ErrPeerUnsupported 远程节点不支持该操作
+	ErrPeerUnsupported = errors.New("PeerUnsupported")
 )
 
+// 默认的过期清理间隔
+const defaultSweepInterval = time.Minute
+
 // 命名空间
 type Group struct {
-	cache       *cache // 从内存获取
-	getter      Getter // 从本地获取
-	name        string
-	peersPicker peers.PeersPicker
+	cache         *cache        // 从内存获取
+	hotCache      *hotCache     // 远程节点数据的热点副本，为空时不开启
+	getter        ContextGetter // 从本地获取
+	batchGetter   BatchGetter   // 批量回源，为空时逐个回源
+	name          string
+	peersPicker   peers.PeersPicker
+	loader        singleflight.Group // 合并对同一个key的并发加载
+	localLoader   singleflight.Group // 合并只在本地回源的并发加载
+	defaultTTL    time.Duration      // 默认过期时间，0表示永不过期
+	sweepInterval time.Duration      // 后台清理过期条目的间隔，0表示不清理
+	stopSweep     chan struct{}
+	closeOnce     sync.Once
+
+	refreshAhead   time.Duration // 距离过期小于该时间时提前刷新，0表示不开启
+	refreshMinHits int64         // 提前刷新要求的最少命中次数
+	maxRefreshes   int           // 后台刷新的最大并发数
+	refreshSem     chan struct{}
+	refreshing     sync.Map // 正在后台刷新的key
+	servedStale    atomic.Int64
+
+	notFoundTTL  time.Duration // 回源未找到时的负缓存时间，0表示不缓存
+	errorTTL     time.Duration // 回源出错时的负缓存时间，0表示不缓存
+	negativeHits atomic.Int64
+
+	setter     Setter      // 写入时同步到数据源，为空时只写缓存
+	deleter    Deleter     // 删除时同步到数据源，为空时只删除缓存
+	writeQueue *writeQueue // write-behind队列，为空时同步写入数据源
+
+	stats  stats
+	budget groupBudget
+}
+
+// GroupOption 创建Group时的可选配置
+type GroupOption func(*Group)
+
+// WithDefaultTTL 设置Group的默认过期时间
+func WithDefaultTTL(ttl time.Duration) GroupOption {
+	return func(g *Group) {
+		g.defaultTTL = ttl
+	}
+}
+
+// WithMaxEntries 设置Group的最大条目数，0表示不限制
+func WithMaxEntries(maxEntries int) GroupOption {
+	return func(g *Group) {
+		g.cache.maxEntries = maxEntries
+	}
+}
+
+// WithShards 设置缓存的分片数，0表示根据容量自动选择
+func WithShards(n int) GroupOption {
+	return func(g *Group) {
+		g.cache.nShards = n
+	}
+}
+
+// WithContextGetter 设置支持ctx的回源函数，替代AddGroup传入的Getter
+func WithContextGetter(getter ContextGetter) GroupOption {
+	return func(g *Group) {
+		g.getter = getter
+	}
+}
+
+// WithPeersPicker 设置Group使用的节点选择器，等同于创建后调用RegisterPicker
+func WithPeersPicker(picker peers.PeersPicker) GroupOption {
+	return func(g *Group) {
+		g.peersPicker = picker
+	}
+}
+
+// WithSweepInterval 设置后台清理过期条目的间隔，0表示关闭后台清理
+func WithSweepInterval(interval time.Duration) GroupOption {
+	return func(g *Group) {
+		g.sweepInterval = interval
+	}
 }
 
 func (g *Group) RegisterPicker(picker peers.PeersPicker) {
 	g.peersPicker = picker
 }
 func (g *Group) Get(key string) (ByteView, error) {
+	return g.GetContext(context.Background(), key)
+}
+
+// GetContext 获取缓存，ctx结束时提前返回，并取消只为该请求进行的远程调用或回源
+func (g *Group) GetContext(ctx context.Context, key string) (ByteView, error) {
 	if key == "" {
 		return ByteView{}, ErrKeyIsNil
 	}
-	peer, ok := g.peersPicker.PickPeer(key)
-	if ok {
-		bs, err := peer.Get(g.name, key)
-		return NewByteView(bs), err
-	} else {
-		return g.getLocally(key)
+	g.stats.gets.Add(1)
+	if byteView, ok, err := g.lookup(key); ok {
+		g.stats.hits.Add(1)
+		return byteView, err
+	}
+	if byteView, ok := g.hotCache.get(key); ok {
+		g.stats.hits.Add(1)
+		return byteView, nil
 	}
+	g.stats.misses.Add(1)
+	return g.load(ctx, key)
 }
 
-// 从本地获取
-func (g *Group) getLocally(key string) (ByteView, error) {
-	byteView, ok := g.cache.get(key)
-	if !ok {
-		// 回源
-		if g.getter != nil {
-			bs, err := g.getter.Get(key)
+// load 从远程节点或本地回源加载，同一个key的并发加载只会执行一次
+func (g *Group) load(ctx context.Context, key string) (ByteView, error) {
+	value, err := g.loader.DoContext(ctx, key, func(ctx context.Context) (any, error) {
+		if peer, ok := g.pickPeer(key); ok {
+			bs, err := peers.GetContext(ctx, peer, g.name, key)
+			g.stats.recordPeerLoad(err)
 			if err != nil {
-				return ByteView{}, err
+				return nil, err
 			}
-			byteView = NewByteView(bs)
-			g.cache.add(key, byteView)
+			byteView := NewByteView(bs)
+			g.hotCache.maybeAdd(key, byteView)
 			return byteView, nil
-		} else {
-			return ByteView{}, ErrKeyNotFound
 		}
+		return g.getLocally(ctx, key)
+	})
+	if err != nil {
+		return ByteView{}, err
+	}
+	return value.(ByteView), nil
+}
+
+// GetLocal 只从本地缓存或回源获取，不转发给远程节点，用于处理其他节点转发来的请求，避免节点间循环转发
+func (g *Group) GetLocal(key string) (ByteView, error) {
+	return g.GetLocalContext(context.Background(), key)
+}
+
+// GetLocalContext 与GetLocal相同，ctx结束时提前返回
+func (g *Group) GetLocalContext(ctx context.Context, key string) (ByteView, error) {
+	if key == "" {
+		return ByteView{}, ErrKeyIsNil
+	}
+	g.stats.gets.Add(1)
+	if byteView, ok, err := g.lookup(key); ok {
+		g.stats.hits.Add(1)
+		return byteView, err
 	}
+	g.stats.misses.Add(1)
+	return g.loadLocally(ctx, key)
+}
+
+// loadLocally 只在本地回源，同一个key的并发回源只会执行一次
+func (g *Group) loadLocally(ctx context.Context, key string) (ByteView, error) {
+	value, err := g.localLoader.DoContext(ctx, key, func(ctx context.Context) (any, error) {
+		return g.getLocally(ctx, key)
+	})
+	if err != nil {
+		return ByteView{}, err
+	}
+	return value.(ByteView), nil
+}
+
+// 从本地获取
+func (g *Group) getLocally(ctx context.Context, key string) (ByteView, error) {
+	// 合并等待期间可能已有其他请求完成加载
+	if it, ok := g.cache.lookup(key); ok && !it.stale(time.Now()) {
+		if it.err != nil {
+			return ByteView{}, it.err
+		}
+		if value, ok := g.cache.view(it); ok {
+			return value, nil
+		}
+	}
+	if g.getter == nil {
+		return ByteView{}, ErrKeyNotFound
+	}
+	// 回源
+	bs, err := g.getter.GetContext(ctx, key)
+	g.stats.recordLoad(err)
+	if err != nil {
+		g.addNegative(key, err)
+		return ByteView{}, err
+	}
+	byteView := NewByteView(bs)
+	g.cache.add(key, byteView, g.expireAt(0))
 	return byteView, nil
 }
+
+// Add 使用默认过期时间添加缓存
 func (g *Group) Add(key string, value ByteView) error {
+	return g.AddWithTTL(key, value, 0)
+}
+
+// AddWithTTL 添加缓存并指定过期时间，ttl<=0时使用Group的默认过期时间，tags为条目附加的标签，用于InvalidateTag，
+// key属于远程节点时写入该节点，保证从任意节点读取都能看到写入的数据。
+// 数据源由key所属的节点写入，见AddLocal
+func (g *Group) AddWithTTL(key string, value ByteView, ttl time.Duration, tags ...string) error {
+	if key == "" {
+		return ErrKeyIsNil
+	}
+	if peer, ok := g.pickPeer(key); ok {
+		setter, ok := peer.(peers.PeerSetter)
+		if !ok {
+			return ErrPeerUnsupported
+		}
+		tagger, ok := peer.(peers.PeerTagger)
+		if !ok && len(tags) > 0 {
+			return ErrPeerUnsupported
+		}
+		// key属于远程节点，写入该节点并删除本地可能存在的旧数据
+		g.cache.remove(key)
+		g.hotCache.remove(key)
+		if len(tags) > 0 {
+			return tagger.SetWithTags(g.name, key, value.ByteSlices(), ttl, tags)
+		}
+		return setter.Set(g.name, key, value.ByteSlices(), ttl)
+	}
+	return g.AddLocal(key, value, ttl, tags...)
+}
+
+// AddLocal 只写入本地缓存，不转发给远程节点，用于处理其他节点转发来的请求。
+// 数据源只由key所属的节点写入，同一个key的写入都经过同一个写队列；设置了Setter时先写入数据源，写入失败时不更新缓存
+func (g *Group) AddLocal(key string, value ByteView, ttl time.Duration, tags ...string) error {
+	if key == "" {
+		return ErrKeyIsNil
+	}
+	if err := g.storeSet(key, value); err != nil {
+		return err
+	}
+	g.cache.add(key, value, g.expireAt(ttl), tags...)
+	return nil
+}
+
+// Delete 删除缓存，key属于远程节点时转发给该节点删除，数据源由key所属的节点删除，见DeleteLocal
+func (g *Group) Delete(key string) error {
+	if key == "" {
+		return ErrKeyIsNil
+	}
+	peer, ok := g.pickPeer(key)
+	if !ok {
+		return g.DeleteLocal(key)
+	}
+	// 本地可能存在旧数据，先删除本地副本
+	g.cache.remove(key)
+	g.hotCache.remove(key)
+	deleter, ok := peer.(peers.PeerDeleter)
+	if !ok {
+		return ErrPeerUnsupported
+	}
+	return deleter.Delete(g.name, key)
+}
+
+// DeleteLocal 只删除本地缓存，不转发给远程节点，用于处理其他节点转发来的请求，
+// 设置了Deleter时先从数据源删除，删除失败时不更新缓存
+func (g *Group) DeleteLocal(key string) error {
 	if key == "" {
 		return ErrKeyIsNil
 	}
-	g.cache.add(key, value)
+	if err := g.storeDelete(key); err != nil {
+		return err
+	}
+	g.cache.remove(key)
+	g.hotCache.remove(key)
 	return nil
 }
+
+// pickPeer 选择key所属的远程节点，未注册PeersPicker时以单机模式运行，所有key都由本地处理
+func (g *Group) pickPeer(key string) (peers.PeerGetter, bool) {
+	if g.peersPicker == nil {
+		return nil, false
+	}
+	return g.peersPicker.PickPeer(key)
+}
+
+// expireAt 计算过期时间点，零值表示永不过期
+func (g *Group) expireAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		ttl = g.defaultTTL
+	}
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+// sweep 定期清理过期条目，使其及时释放占用的字节
+func (g *Group) sweep() {
+	ticker := time.NewTicker(g.sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			g.cache.removeExpired()
+			g.hotCache.removeExpired()
+		case <-g.stopSweep:
+			return
+		}
+	}
+}
+
+// Flush 清空本地缓存和热点缓存，不影响远程节点上的数据
+func (g *Group) Flush() {
+	g.cache.flush()
+	g.hotCache.flush()
+}
+
+// Resize 调整本地缓存的字节数上限，缩小时超出容量的条目会被淘汰
+func (g *Group) Resize(maxBytes int64) {
+	maxEntries, _ := g.cache.capacity()
+	g.cache.resize(maxEntries, maxBytes)
+}
+
+// Close 停止后台清理，开启write-behind时等待队列中的数据写入数据源
+func (g *Group) Close() {
+	g.closeOnce.Do(func() {
+		if g.stopSweep != nil {
+			close(g.stopSweep)
+		}
+		if g.writeQueue != nil {
+			g.writeQueue.close()
+		}
+	})
+}
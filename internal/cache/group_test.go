@@ -0,0 +1,277 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"zencache/internal/peers"
+)
+
+func TestGroup_AddWithTTL(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("ttl", nil, 100, WithDefaultTTL(time.Hour), WithSweepInterval(0))
+	g := e.GetGroup("ttl")
+
+	if err := g.AddWithTTL("short", NewByteView([]byte("v")), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Add("long", NewByteView([]byte("v"))); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := g.GetLocal("short"); err != ErrKeyNotFound {
+		t.Errorf("expected short to expire, got err %v", err)
+	}
+	if _, err := g.GetLocal("long"); err != nil {
+		t.Errorf("expected long to use default ttl, got err %v", err)
+	}
+}
+
+func TestGroup_Sweep(t *testing.T) {
+	e := NewEngine()
+	defer e.Close()
+	e.AddGroup("sweep", nil, 100, WithSweepInterval(time.Millisecond))
+	g := e.GetGroup("sweep")
+
+	g.AddWithTTL("k", NewByteView([]byte("v")), time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if g.cache.bytes() == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("expected sweeper to release bytes of expired entry")
+}
+
+// testPicker 将所有key都分配给同一个节点，peer为空时表示由本地处理
+type testPicker struct {
+	peer peers.PeerGetter
+}
+
+func (p testPicker) PickPeer(key string) (peers.PeerGetter, bool) {
+	return p.peer, p.peer != nil
+}
+
+type testPeer struct {
+	calls atomic.Int32
+	delay time.Duration
+}
+
+func (p *testPeer) Get(group string, key string) ([]byte, error) {
+	p.calls.Add(1)
+	time.Sleep(p.delay)
+	return []byte("peer " + key), nil
+}
+
+func TestGroup_GetCoalescesLoads(t *testing.T) {
+	var calls atomic.Int32
+	e := NewEngine()
+	e.AddGroup("flight", GetterFunc(func(key string) ([]byte, error) {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return []byte("value"), nil
+	}), 100, WithSweepInterval(0))
+	g := e.GetGroup("flight")
+	g.RegisterPicker(testPicker{})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := g.Get("k"); err != nil || v.String() != "value" {
+				t.Errorf("unexpected result %q %v", v.String(), err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected getter to be called once, got %d", n)
+	}
+}
+
+func TestGroup_GetCoalescesPeerLoads(t *testing.T) {
+	peer := &testPeer{delay: 20 * time.Millisecond}
+	e := NewEngine()
+	e.AddGroup("flight", nil, 100, WithSweepInterval(0))
+	g := e.GetGroup("flight")
+	g.RegisterPicker(testPicker{peer: peer})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := g.Get("k"); err != nil || v.String() != "peer k" {
+				t.Errorf("unexpected result %q %v", v.String(), err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := peer.calls.Load(); n != 1 {
+		t.Errorf("expected peer to be called once, got %d", n)
+	}
+}
+
+type testDeleter struct {
+	testPeer
+	deleted []string
+}
+
+func (p *testDeleter) Delete(group string, key string) error {
+	p.deleted = append(p.deleted, group+"/"+key)
+	return nil
+}
+
+func TestGroup_Delete(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("del", nil, 100, WithSweepInterval(0))
+	g := e.GetGroup("del")
+	g.RegisterPicker(testPicker{})
+
+	g.Add("k", NewByteView([]byte("v")))
+	if err := g.Delete("k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Get("k"); err != ErrKeyNotFound {
+		t.Errorf("expected ErrKeyNotFound after delete, got %v", err)
+	}
+	if n := g.cache.bytes(); n != 0 {
+		t.Errorf("expected bytes to be released, got %d", n)
+	}
+}
+
+func TestGroup_DeleteRoutesToPeer(t *testing.T) {
+	peer := &testDeleter{}
+	e := NewEngine()
+	e.AddGroup("del", nil, 100, WithSweepInterval(0))
+	g := e.GetGroup("del")
+	g.RegisterPicker(testPicker{peer: peer})
+
+	if err := g.Delete("k"); err != nil {
+		t.Fatal(err)
+	}
+	if len(peer.deleted) != 1 || peer.deleted[0] != "del/k" {
+		t.Errorf("expected delete to be forwarded to owner, got %v", peer.deleted)
+	}
+
+	g.RegisterPicker(testPicker{peer: &testPeer{}})
+	if err := g.Delete("k"); err != ErrPeerUnsupported {
+		t.Errorf("expected ErrPeerUnsupported, got %v", err)
+	}
+}
+
+type testSetter struct {
+	testPeer
+	stored map[string]string
+}
+
+func (p *testSetter) Set(group string, key string, value []byte, ttl time.Duration) error {
+	p.stored[group+"/"+key] = string(value)
+	return nil
+}
+
+func TestGroup_AddRoutesToPeer(t *testing.T) {
+	peer := &testSetter{stored: make(map[string]string)}
+	e := NewEngine()
+	e.AddGroup("set", nil, 100, WithSweepInterval(0))
+	g := e.GetGroup("set")
+	g.RegisterPicker(testPicker{peer: peer})
+
+	if err := g.Add("k", NewByteView([]byte("v"))); err != nil {
+		t.Fatal(err)
+	}
+	if peer.stored["set/k"] != "v" {
+		t.Errorf("expected write to land on owner, got %v", peer.stored)
+	}
+	if _, ok := g.cache.get("k"); ok {
+		t.Error("value owned by a peer should not be stored locally")
+	}
+}
+
+func TestGroup_GetStandalone(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("standalone", GetterFunc(func(key string) ([]byte, error) {
+		return []byte("loaded " + key), nil
+	}), 100, WithSweepInterval(0))
+	g := e.GetGroup("standalone")
+
+	v, err := g.Get("k")
+	if err != nil || v.String() != "loaded k" {
+		t.Errorf("expected group without picker to load locally, got %q %v", v.String(), err)
+	}
+}
+
+func TestGroup_HotCache(t *testing.T) {
+	peer := &testDeleter{}
+	e := NewEngine()
+	e.AddGroup("hot", nil, 100, WithSweepInterval(0), WithHotCache(100, time.Hour, 1))
+	g := e.GetGroup("hot")
+	g.RegisterPicker(testPicker{peer: peer})
+
+	for range 3 {
+		if v, err := g.Get("k"); err != nil || v.String() != "peer k" {
+			t.Fatalf("unexpected result %q %v", v.String(), err)
+		}
+	}
+	if n := peer.calls.Load(); n != 1 {
+		t.Errorf("expected hot cache to serve repeated reads, peer called %d times", n)
+	}
+	if _, ok := g.cache.get("k"); ok {
+		t.Error("peer values should not be stored in the main cache")
+	}
+
+	// 删除时清除热点副本
+	g.Delete("k")
+	g.Get("k")
+	if n := peer.calls.Load(); n != 2 {
+		t.Errorf("expected delete to purge hot cache, peer called %d times", n)
+	}
+}
+
+func TestGroup_HotCacheExpire(t *testing.T) {
+	peer := &testPeer{}
+	e := NewEngine()
+	e.AddGroup("hot", nil, 100, WithSweepInterval(0), WithHotCache(100, time.Millisecond, 1))
+	g := e.GetGroup("hot")
+	g.RegisterPicker(testPicker{peer: peer})
+
+	g.Get("k")
+	time.Sleep(5 * time.Millisecond)
+	g.Get("k")
+	if n := peer.calls.Load(); n != 2 {
+		t.Errorf("expected hot copy to expire, peer called %d times", n)
+	}
+}
+
+func TestGroup_GetContextCancel(t *testing.T) {
+	loaderDone := make(chan error, 1)
+	e := NewEngine()
+	e.AddGroup("ctx", nil, 100, WithSweepInterval(0),
+		WithContextGetter(ContextGetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+			<-ctx.Done()
+			loaderDone <- ctx.Err()
+			return nil, ctx.Err()
+		})), WithNegativeCache(time.Hour, time.Hour))
+	g := e.GetGroup("ctx")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := g.GetContext(ctx, "k"); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	select {
+	case <-loaderDone:
+	case <-time.After(time.Second):
+		t.Fatal("expected loader to be cancelled with the request")
+	}
+	// 取消不应被当作回源结果缓存
+	waitFor(t, func() bool {
+		_, ok := g.cache.lookup("k")
+		return !ok
+	})
+}
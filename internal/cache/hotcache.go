@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// hotCache 保存从远程节点获取的热点数据副本，减少对热点key的远程调用，
+// 只按采样比例缓存一部分数据，并使用较短的过期时间控制数据不一致的窗口
+type hotCache struct {
+	cache      *cache
+	ttl        time.Duration
+	sampleRate float64 // 采样比例，取值(0, 1]
+}
+
+// WithHotCache 开启热点缓存，maxBytes为独立的容量，ttl为副本的过期时间，
+// sampleRate为从远程节点获取的数据被缓存的比例
+func WithHotCache(maxBytes int64, ttl time.Duration, sampleRate float64) GroupOption {
+	return func(g *Group) {
+		g.hotCache = &hotCache{
+			cache:      &cache{maxBytes: maxBytes},
+			ttl:        ttl,
+			sampleRate: sampleRate,
+		}
+	}
+}
+
+func (h *hotCache) get(key string) (ByteView, bool) {
+	if h == nil {
+		return ByteView{}, false
+	}
+	return h.cache.get(key)
+}
+
+// maybeAdd 按采样比例缓存从远程节点获取的数据
+func (h *hotCache) maybeAdd(key string, value ByteView) {
+	if h == nil || rand.Float64() >= h.sampleRate {
+		return
+	}
+	h.cache.add(key, value, time.Now().Add(h.ttl))
+}
+
+func (h *hotCache) remove(key string) {
+	if h != nil {
+		h.cache.remove(key)
+	}
+}
+
+func (h *hotCache) flush() {
+	if h != nil {
+		h.cache.flush()
+	}
+}
+
+func (h *hotCache) removeExpired() {
+	if h != nil {
+		h.cache.removeExpired()
+	}
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WithNegativeCache 开启负缓存：回源返回ErrKeyNotFound时缓存notFoundTTL，
+// 返回其他错误时缓存errorTTL，为0时不缓存对应的结果
+func WithNegativeCache(notFoundTTL time.Duration, errorTTL time.Duration) GroupOption {
+	return func(g *Group) {
+		g.notFoundTTL = notFoundTTL
+		g.errorTTL = errorTTL
+	}
+}
+
+// addNegative 按配置缓存回源返回的错误，负缓存条目与普通条目共用容量
+func (g *Group) addNegative(key string, err error) {
+	// 请求被取消或超时不代表回源的结果
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	ttl := g.errorTTL
+	if errors.Is(err, ErrKeyNotFound) {
+		ttl = g.notFoundTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	g.cache.addNegative(key, err, time.Now().Add(ttl))
+}
+
+// trackNegative 记录负缓存条目，并清除同一个key旧的负缓存条目，调用方需持有分片锁
+func (s *shard) trackNegative(key string, it *item) {
+	if old, ok := s.negative[key]; ok {
+		s.untrackNegative(key, old)
+	}
+	if it.err == nil {
+		return
+	}
+	if s.negative == nil {
+		s.negative = make(map[string]*item)
+	}
+	s.negative[key] = it
+	s.negativeBytes += int64(len(key) + it.Len())
+}
+
+// untrackNegative 条目被淘汰、删除或替换时清除其记录，key已被新条目替换时不处理，调用方需持有分片锁
+func (s *shard) untrackNegative(key string, it *item) {
+	if it == nil || s.negative[key] != it {
+		return
+	}
+	delete(s.negative, key)
+	s.negativeBytes -= int64(len(key) + it.Len())
+}
+
+// tombstones 返回负缓存条目数及其占用的字节数
+func (c *cache) tombstones() (int, int64) {
+	n, bytes := 0, int64(0)
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += len(s.negative)
+		bytes += s.negativeBytes
+		s.mu.Unlock()
+	}
+	return n, bytes
+}
+
+// NegativeHits 返回命中负缓存的次数
+func (g *Group) NegativeHits() int64 {
+	return g.negativeHits.Load()
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup_NegativeCacheNotFound(t *testing.T) {
+	var calls atomic.Int32
+	e := NewEngine()
+	e.AddGroup("neg", GetterFunc(func(key string) ([]byte, error) {
+		calls.Add(1)
+		return nil, ErrKeyNotFound
+	}), 100, WithSweepInterval(0), WithNegativeCache(time.Hour, 0))
+	g := e.GetGroup("neg")
+
+	for range 3 {
+		if _, err := g.Get("missing"); err != ErrKeyNotFound {
+			t.Fatalf("expected ErrKeyNotFound, got %v", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected not found to be cached, getter called %d times", n)
+	}
+	if n := g.NegativeHits(); n != 2 {
+		t.Errorf("expected 2 negative hits, got %d", n)
+	}
+	// 负缓存条目占用同一个LRU的容量
+	if g.cache.bytes() == 0 {
+		t.Error("expected tombstone to be charged against the cache")
+	}
+
+	// 写入后覆盖负缓存条目
+	g.Add("missing", NewByteView([]byte("v")))
+	if v, err := g.Get("missing"); err != nil || v.String() != "v" {
+		t.Errorf("expected stored value to replace tombstone, got %q %v", v.String(), err)
+	}
+}
+
+func TestGroup_NegativeCacheErrors(t *testing.T) {
+	var calls atomic.Int32
+	backendErr := errors.New("backend down")
+	e := NewEngine()
+	e.AddGroup("neg", GetterFunc(func(key string) ([]byte, error) {
+		calls.Add(1)
+		return nil, backendErr
+	}), 100, WithSweepInterval(0), WithNegativeCache(time.Hour, time.Millisecond))
+	g := e.GetGroup("neg")
+
+	g.Get("k")
+	if _, err := g.Get("k"); err != backendErr {
+		t.Errorf("expected cached backend error, got %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected error to be cached, getter called %d times", n)
+	}
+	time.Sleep(5 * time.Millisecond)
+	g.Get("k")
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected cached error to expire, getter called %d times", n)
+	}
+}
+
+func TestGroup_NegativeCacheDisabled(t *testing.T) {
+	var calls atomic.Int32
+	e := NewEngine()
+	e.AddGroup("neg", GetterFunc(func(key string) ([]byte, error) {
+		calls.Add(1)
+		return nil, ErrKeyNotFound
+	}), 100, WithSweepInterval(0))
+	g := e.GetGroup("neg")
+
+	g.Get("k")
+	g.Get("k")
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected no negative caching by default, getter called %d times", n)
+	}
+}
+
+func TestGroup_NegativeCacheStats(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("neg", GetterFunc(func(key string) ([]byte, error) {
+		return nil, ErrKeyNotFound
+	}), 100, WithSweepInterval(0), WithNegativeCache(time.Hour, 0))
+	g := e.GetGroup("neg")
+
+	g.Get("a")
+	g.Get("b")
+	stats := g.Stats()
+	if stats.Tombstones != 2 || stats.TombstoneBytes <= 0 {
+		t.Fatalf("expected 2 tombstones, got %d (%d bytes)", stats.Tombstones, stats.TombstoneBytes)
+	}
+	if stats.TombstoneBytes > stats.Bytes {
+		t.Errorf("expected tombstone bytes within total bytes, got %d > %d", stats.TombstoneBytes, stats.Bytes)
+	}
+
+	// 写入覆盖负缓存条目
+	g.Add("a", NewByteView([]byte("v")))
+	if stats := g.Stats(); stats.Tombstones != 1 {
+		t.Errorf("expected the overwritten tombstone to be dropped, got %d", stats.Tombstones)
+	}
+
+	// 容量不足淘汰负缓存条目
+	g.Add("big", NewByteView(make([]byte, 90)))
+	if stats := g.Stats(); stats.Tombstones != 0 || stats.TombstoneBytes != 0 {
+		t.Errorf("expected evicted tombstones to be dropped, got %d (%d bytes)", stats.Tombstones, stats.TombstoneBytes)
+	}
+}
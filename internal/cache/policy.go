@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"fmt"
+	"zencache/internal/eviction"
+	"zencache/internal/lfu"
+	"zencache/internal/lru"
+	"zencache/internal/tinylfu"
+)
+
+// 内置的淘汰策略名称
+const (
+	PolicyLRU     = "lru"
+	PolicyLFU     = "lfu"
+	PolicyTinyLFU = "tinylfu"
+)
+
+var policies = map[string]eviction.Factory{
+	PolicyLRU: func(maxEntries int, maxBytes int64, onEvicted func(string, eviction.Value)) eviction.Policy {
+		return lru.NewWithMaxEntries(maxEntries, maxBytes, onEvicted)
+	},
+	PolicyLFU: func(maxEntries int, maxBytes int64, onEvicted func(string, eviction.Value)) eviction.Policy {
+		return lfu.NewWithMaxEntries(maxEntries, maxBytes, onEvicted)
+	},
+	PolicyTinyLFU: func(maxEntries int, maxBytes int64, onEvicted func(string, eviction.Value)) eviction.Policy {
+		return tinylfu.NewWithMaxEntries(maxEntries, maxBytes, onEvicted)
+	},
+}
+
+// PolicyByName 根据名称获取内置淘汰策略，名称为空时使用LRU
+func PolicyByName(name string) (eviction.Factory, error) {
+	if name == "" {
+		name = PolicyLRU
+	}
+	factory, ok := policies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown eviction policy: %s", name)
+	}
+	return factory, nil
+}
+
+// WithPolicy 设置Group使用的淘汰策略
+func WithPolicy(factory eviction.Factory) GroupOption {
+	return func(g *Group) {
+		g.cache.newPolicy = factory
+	}
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// defaultMaxRefreshes 默认的后台刷新并发数
+const defaultMaxRefreshes = 16
+
+// WithStaleWhileRevalidate 条目过期后的window时间内仍返回旧数据，同时在后台通过Getter刷新
+func WithStaleWhileRevalidate(window time.Duration) GroupOption {
+	return func(g *Group) {
+		g.cache.staleWindow = window
+	}
+}
+
+// WithRefreshAhead 命中次数不少于minHits的条目在距离过期小于window时提前在后台刷新
+func WithRefreshAhead(window time.Duration, minHits int64) GroupOption {
+	return func(g *Group) {
+		g.refreshAhead = window
+		g.refreshMinHits = minHits
+	}
+}
+
+// WithMaxConcurrentRefreshes 限制同时进行的后台刷新数，超出时跳过本次刷新
+func WithMaxConcurrentRefreshes(n int) GroupOption {
+	return func(g *Group) {
+		g.maxRefreshes = n
+	}
+}
+
+// lookup 从本地缓存获取，过期的条目在允许时作为旧数据返回，并按需触发后台刷新，
+// 命中负缓存条目时返回其记录的错误
+func (g *Group) lookup(key string) (ByteView, bool, error) {
+	it, ok := g.cache.lookup(key)
+	if !ok {
+		return ByteView{}, false, nil
+	}
+	now := time.Now()
+	if it.err != nil {
+		if it.stale(now) {
+			return ByteView{}, false, nil
+		}
+		g.negativeHits.Add(1)
+		return ByteView{}, true, it.err
+	}
+	if it.stale(now) {
+		if g.cache.staleWindow <= 0 || g.getter == nil {
+			return ByteView{}, false, nil
+		}
+		g.servedStale.Add(1)
+		g.refresh(key)
+		value, ok := g.cache.view(it)
+		return value, ok, nil
+	}
+	if g.refreshAhead > 0 && g.getter != nil && !it.expire.IsZero() &&
+		it.expire.Sub(now) < g.refreshAhead && it.hits.Load() >= g.refreshMinHits {
+		g.refresh(key)
+	}
+	value, ok := g.cache.view(it)
+	return value, ok, nil
+}
+
+// refresh 在后台通过Getter重新加载，同一个key同时只有一个刷新，超出并发限制时跳过
+func (g *Group) refresh(key string) {
+	if _, loaded := g.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	select {
+	case g.refreshSem <- struct{}{}:
+	default:
+		g.refreshing.Delete(key)
+		return
+	}
+	go func() {
+		defer func() {
+			<-g.refreshSem
+			g.refreshing.Delete(key)
+		}()
+		bs, err := g.getter.GetContext(context.Background(), key)
+		g.stats.recordLoad(err)
+		if err != nil {
+			log.Printf("group %s: refresh %s: %v", g.name, key, err)
+			return
+		}
+		// 刷新只更新数据，保留写入时附加的标签
+		g.cache.addKeepTags(key, NewByteView(bs), g.expireAt(0))
+	}()
+}
+
+// ServedStale 返回作为旧数据返回的次数
+func (g *Group) ServedStale() int64 {
+	return g.servedStale.Load()
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingGetter 每次加载返回递增的版本号
+type countingGetter struct {
+	calls atomic.Int32
+	delay time.Duration
+}
+
+func (g *countingGetter) Get(key string) ([]byte, error) {
+	n := g.calls.Add(1)
+	time.Sleep(g.delay)
+	return []byte(fmt.Sprintf("%s-v%d", key, n)), nil
+}
+
+// waitFor 等待条件成立，超时则测试失败
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGroup_StaleWhileRevalidate(t *testing.T) {
+	getter := &countingGetter{delay: 20 * time.Millisecond}
+	e := NewEngine()
+	e.AddGroup("swr", getter, 100, WithSweepInterval(0),
+		WithDefaultTTL(time.Millisecond), WithStaleWhileRevalidate(time.Hour))
+	g := e.GetGroup("swr")
+
+	if v, _ := g.Get("k"); v.String() != "k-v1" {
+		t.Fatalf("expected initial load, got %q", v.String())
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// 过期后立即返回旧数据，只触发一次后台刷新
+	for range 5 {
+		if v, err := g.Get("k"); err != nil || v.String() != "k-v1" {
+			t.Fatalf("expected stale value, got %q %v", v.String(), err)
+		}
+	}
+	if n := g.ServedStale(); n != 5 {
+		t.Errorf("expected 5 stale responses, got %d", n)
+	}
+	waitFor(t, func() bool {
+		v, _ := g.cache.lookup("k")
+		return v != nil && v.value.String() == "k-v2"
+	})
+	if n := getter.calls.Load(); n != 2 {
+		t.Errorf("expected a single background refresh, getter called %d times", n)
+	}
+}
+
+func TestGroup_StaleDisabled(t *testing.T) {
+	getter := &countingGetter{}
+	e := NewEngine()
+	e.AddGroup("nostale", getter, 100, WithSweepInterval(0), WithDefaultTTL(time.Millisecond))
+	g := e.GetGroup("nostale")
+
+	g.Get("k")
+	time.Sleep(5 * time.Millisecond)
+	if v, _ := g.Get("k"); v.String() != "k-v2" {
+		t.Errorf("expected a synchronous reload without stale-while-revalidate, got %q", v.String())
+	}
+	if g.ServedStale() != 0 {
+		t.Error("no stale responses expected")
+	}
+}
+
+func TestGroup_RefreshAhead(t *testing.T) {
+	getter := &countingGetter{}
+	e := NewEngine()
+	e.AddGroup("ahead", getter, 100, WithSweepInterval(0),
+		WithDefaultTTL(50*time.Millisecond), WithRefreshAhead(40*time.Millisecond, 3))
+	g := e.GetGroup("ahead")
+
+	g.Get("k")
+	time.Sleep(20 * time.Millisecond)
+	for range 3 {
+		g.Get("k")
+	}
+	waitFor(t, func() bool { return getter.calls.Load() == 2 })
+	waitFor(t, func() bool {
+		v, ok := g.cache.get("k")
+		return ok && v.String() == "k-v2"
+	})
+}
+
+func TestGroup_MaxConcurrentRefreshes(t *testing.T) {
+	getter := &countingGetter{delay: 50 * time.Millisecond}
+	e := NewEngine()
+	e.AddGroup("limit", getter, 1000, WithSweepInterval(0), WithDefaultTTL(time.Millisecond),
+		WithStaleWhileRevalidate(time.Hour), WithMaxConcurrentRefreshes(1))
+	g := e.GetGroup("limit")
+
+	g.Get("a")
+	g.Get("b")
+	time.Sleep(5 * time.Millisecond)
+	g.Get("a")
+	g.Get("b") // 已有一个刷新在进行，跳过
+	time.Sleep(100 * time.Millisecond)
+	if n := getter.calls.Load(); n != 3 {
+		t.Errorf("expected only one concurrent refresh, getter called %d times", n)
+	}
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"zencache/internal/peers"
+)
+
+// 本节点没有Group时，读取和删除请求不创建Group，直接转发给key所属的远程节点；
+// key属于本节点时说明数据不可能存在，返回ErrGroupNotFound
+
+// GetOnPeer 从key所属的远程节点获取group中的缓存
+func GetOnPeer(ctx context.Context, picker peers.PeersPicker, group string, key string) (ByteView, error) {
+	peer, err := ownerPeer(picker, key)
+	if err != nil {
+		return ByteView{}, err
+	}
+	bs, err := peers.GetContext(ctx, peer, group, key)
+	if err != nil {
+		return ByteView{}, err
+	}
+	return NewByteView(bs), nil
+}
+
+// GetVersionOnPeer 与GetOnPeer相同，同时返回条目的版本号
+func GetVersionOnPeer(ctx context.Context, picker peers.PeersPicker, group string, key string) (ByteView, uint64, error) {
+	peer, err := ownerPeer(picker, key)
+	if err != nil {
+		return ByteView{}, 0, err
+	}
+	versioner, ok := peer.(peers.PeerVersioner)
+	if !ok {
+		return ByteView{}, 0, ErrPeerUnsupported
+	}
+	bs, version, err := versioner.GetVersion(ctx, group, key)
+	if err != nil {
+		return ByteView{}, 0, err
+	}
+	return NewByteView(bs), version, nil
+}
+
+// DeleteOnPeer 删除key所属的远程节点上group中的缓存
+func DeleteOnPeer(picker peers.PeersPicker, group string, key string) error {
+	peer, err := ownerPeer(picker, key)
+	if err != nil {
+		return err
+	}
+	deleter, ok := peer.(peers.PeerDeleter)
+	if !ok {
+		return ErrPeerUnsupported
+	}
+	return deleter.Delete(group, key)
+}
+
+// GetManyOnPeers 按所属节点分组批量获取group中的缓存，每个远程节点只发送一次批量请求，
+// 属于本节点的key对应ErrGroupNotFound
+func GetManyOnPeers(ctx context.Context, picker peers.PeersPicker, group string, keys []string) map[string]Result {
+	results := make(map[string]Result, len(keys))
+	var mutex sync.Mutex
+	set := func(key string, r Result) {
+		mutex.Lock()
+		results[key] = r
+		mutex.Unlock()
+	}
+
+	seen := make(map[string]struct{}, len(keys))
+	remote := make(map[peers.PeerGetter][]string)
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		peer, err := ownerPeer(picker, key)
+		if err != nil {
+			results[key] = Result{Err: err}
+			continue
+		}
+		remote[peer] = append(remote[peer], key)
+	}
+
+	var wg sync.WaitGroup
+	for peer, keys := range remote {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			batch, ok := peer.(peers.PeerBatchGetter)
+			if !ok {
+				for _, key := range keys {
+					bs, err := peers.GetContext(ctx, peer, group, key)
+					set(key, Result{Value: NewByteView(bs), Err: err})
+				}
+				return
+			}
+			values, err := batch.GetMany(ctx, group, keys)
+			for _, key := range keys {
+				r, ok := values[key]
+				switch {
+				case err != nil:
+					set(key, Result{Err: err})
+				case !ok:
+					set(key, Result{Err: ErrKeyNotFound})
+				default:
+					set(key, Result{Value: NewByteView(r.Value), Err: r.Err})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// ownerPeer 返回key所属的远程节点，key为空时返回ErrKeyIsNil，属于本节点时返回ErrGroupNotFound
+func ownerPeer(picker peers.PeersPicker, key string) (peers.PeerGetter, error) {
+	if key == "" {
+		return nil, ErrKeyIsNil
+	}
+	if picker == nil {
+		return nil, ErrGroupNotFound
+	}
+	peer, ok := picker.PickPeer(key)
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	return peer, nil
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// Stats Group的统计信息
+type Stats struct {
+	Gets         int64 // 获取的key数
+	Hits         int64 // 命中本地缓存或热点缓存的次数，包括返回旧数据和命中负缓存
+	Misses       int64 // 未命中需要加载的次数
+	Loads        int64 // 通过Getter回源的key数，包括后台刷新
+	LoadErrors   int64 // 回源返回的错误数，不包括ErrKeyNotFound
+	PeerLoads    int64 // 从远程节点获取的key数
+	PeerErrors   int64 // 从远程节点获取返回的错误数，不包括ErrKeyNotFound
+	Evictions    int64 // 因容量不足被淘汰的条目数
+	ServedStale  int64 // 返回旧数据的次数
+	NegativeHits int64 // 命中负缓存的次数
+	Bytes        int64 // 当前占用的字节数，包括负缓存条目
+	MaxBytes     int64 // 字节数上限
+	Items        int   // 当前的条目数，包括负缓存条目
+	// Tombstones 当前的负缓存条目数，TombstoneBytes为其占用的字节数
+	Tombstones     int
+	TombstoneBytes int64
+	// CompressionRatio 开启压缩后写入的值的原始大小与保存大小之比，未开启压缩时为0
+	CompressionRatio float64
+}
+
+// stats 热路径上只做原子加法，读取时再汇总
+type stats struct {
+	gets       atomic.Int64
+	hits       atomic.Int64
+	misses     atomic.Int64
+	loads      atomic.Int64
+	loadErrors atomic.Int64
+	peerLoads  atomic.Int64
+	peerErrors atomic.Int64
+}
+
+// recordLoad 记录一次回源及其结果
+func (s *stats) recordLoad(err error) {
+	s.loads.Add(1)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		s.loadErrors.Add(1)
+	}
+}
+
+// recordPeerLoad 记录一次从远程节点获取及其结果
+func (s *stats) recordPeerLoad(err error) {
+	s.peerLoads.Add(1)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		s.peerErrors.Add(1)
+	}
+}
+
+// Stats 返回Group的统计信息
+func (g *Group) Stats() Stats {
+	_, maxBytes := g.cache.capacity()
+	tombstones, tombstoneBytes := g.cache.tombstones()
+	return Stats{
+		Gets:         g.stats.gets.Load(),
+		Hits:         g.stats.hits.Load(),
+		Misses:       g.stats.misses.Load(),
+		Loads:        g.stats.loads.Load(),
+		LoadErrors:   g.stats.loadErrors.Load(),
+		PeerLoads:    g.stats.peerLoads.Load(),
+		PeerErrors:   g.stats.peerErrors.Load(),
+		Evictions:    g.cache.evictions.Load(),
+		ServedStale:  g.servedStale.Load(),
+		NegativeHits: g.negativeHits.Load(),
+		Bytes:        g.cache.bytes(),
+		MaxBytes:     maxBytes,
+		Items:        g.cache.len(),
+
+		Tombstones:     tombstones,
+		TombstoneBytes: tombstoneBytes,
+
+		CompressionRatio: g.cache.compressionRatio(),
+	}
+}
+
+// Stats 返回所有Group的统计信息，key为Group名称
+func (e *Engine) Stats() map[string]Stats {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	stats := make(map[string]Stats, len(e.groups))
+	for name, g := range e.groups {
+		stats[name] = g.Stats()
+	}
+	return stats
+}
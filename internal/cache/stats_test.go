@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestGroup_Stats(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("stats", GetterFunc(func(key string) ([]byte, error) {
+		switch key {
+		case "missing":
+			return nil, ErrKeyNotFound
+		case "broken":
+			return nil, errors.New("boom")
+		}
+		return []byte(key), nil
+	}), 1<<10, WithSweepInterval(0))
+	g := e.GetGroup("stats")
+
+	g.Get("a")
+	g.Get("a")
+	g.Get("missing")
+	g.Get("broken")
+
+	s := g.Stats()
+	want := Stats{Gets: 4, Hits: 1, Misses: 3, Loads: 3, LoadErrors: 1, Bytes: 2, MaxBytes: 1 << 10, Items: 1}
+	if s != want {
+		t.Errorf("expected %+v, got %+v", want, s)
+	}
+	if all := e.Stats(); all["stats"] != s {
+		t.Errorf("expected engine stats to include the group, got %+v", all)
+	}
+}
+
+func TestGroup_StatsPeer(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("stats-peer", nil, 1<<10, WithSweepInterval(0), WithPeersPicker(testPicker{&testPeer{}}))
+	g := e.GetGroup("stats-peer")
+
+	g.Get("k")
+	if s := g.Stats(); s.PeerLoads != 1 || s.PeerErrors != 0 || s.Loads != 0 {
+		t.Errorf("expected one peer load, got %+v", s)
+	}
+}
+
+func TestGroup_StatsEvictions(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("evict", nil, 100, WithSweepInterval(0), WithShards(1))
+	g := e.GetGroup("evict")
+
+	for i := range 20 {
+		g.AddLocal(fmt.Sprintf("key%02d", i), NewByteView(make([]byte, 10)), 0)
+	}
+	g.DeleteLocal("key19")
+	s := g.Stats()
+	// 每个条目占15字节，容量100最多保留6个
+	if s.Evictions != 14 || s.Items != 5 {
+		t.Errorf("expected 14 evictions and 5 items left, got %+v", s)
+	}
+}
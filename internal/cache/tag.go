@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"zencache/internal/peers"
+)
+
+// ErrTagIsNil 标签为空
+var ErrTagIsNil = errors.New("TagIsNil")
+
+// InvalidateTag 删除本地和所有远程节点上带有tag的缓存，返回删除的条目数，
+// 部分节点失败时仍会返回其他节点删除的条目数和合并后的错误
+func (g *Group) InvalidateTag(tag string) (int, error) {
+	if tag == "" {
+		return 0, ErrTagIsNil
+	}
+	removed := g.InvalidateTagLocal(tag)
+	n, err := InvalidateTagOnPeers(g.peersPicker, g.name, tag)
+	return removed + n, err
+}
+
+// InvalidateTagOnPeers 删除所有远程节点上group中带有tag的缓存，picker不支持列出节点时不做处理，
+// 用于本节点没有该Group时仍需要广播给其他节点的情况
+func InvalidateTagOnPeers(picker peers.PeersPicker, group string, tag string) (int, error) {
+	if tag == "" {
+		return 0, ErrTagIsNil
+	}
+	lister, ok := picker.(peers.PeerLister)
+	if !ok {
+		return 0, nil
+	}
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		removed int
+		errs    []error
+	)
+	for _, peer := range lister.ListPeers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := 0, ErrPeerUnsupported
+			if tagger, ok := peer.(peers.PeerTagger); ok {
+				n, err = tagger.InvalidateTag(group, tag)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			removed += n
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return removed, errors.Join(errs...)
+}
+
+// InvalidateTagLocal 只删除本地带有tag的缓存，不转发给远程节点，用于处理其他节点转发来的请求。
+// 热点缓存中的副本不带标签，无法判断是否属于该标签，因此会被全部清空
+func (g *Group) InvalidateTagLocal(tag string) int {
+	removed := g.cache.removeTag(tag)
+	g.hotCache.flush()
+	return removed
+}
+
+// removeTag 删除带有tag的所有条目，返回删除的数量
+func (c *cache) removeTag(tag string) int {
+	removed := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		// 删除条目时回调会从索引中删除key，遍历时删除map中的元素是安全的
+		for key := range s.tags[tag] {
+			if s.policy.Remove(key) {
+				removed++
+			} else {
+				s.unindex(key, s.tagged[key])
+			}
+		}
+		c.unlock(s)
+	}
+	return removed
+}
+
+// addKeepTags 添加缓存并保留当前条目的标签，用于后台刷新
+func (c *cache) addKeepTags(key string, value ByteView, expire time.Time) {
+	value, compressed := c.encode(value)
+	it := &item{value: value, compressed: compressed, created: time.Now(), expire: expire}
+	s := c.shard(key)
+	s.mu.Lock()
+	defer c.shrink(key)
+	defer c.unlock(s)
+	if old, ok := s.tagged[key]; ok {
+		it.tags = old.tags
+	}
+	c.addLocked(s, key, it)
+}
+
+// index 为条目建立标签索引，并清除同一个key旧条目的索引，调用方需持有分片锁
+func (s *shard) index(key string, it *item) {
+	if old, ok := s.tagged[key]; ok {
+		s.unindex(key, old)
+	}
+	if len(it.tags) == 0 {
+		return
+	}
+	if s.tagged == nil {
+		s.tagged = make(map[string]*item)
+		s.tags = make(map[string]map[string]struct{})
+	}
+	s.tagged[key] = it
+	for _, tag := range it.tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			s.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// unindex 清除条目的标签索引，key已被新条目替换时不处理，调用方需持有分片锁
+func (s *shard) unindex(key string, it *item) {
+	if it == nil || s.tagged[key] != it {
+		return
+	}
+	delete(s.tagged, key)
+	for _, tag := range it.tags {
+		keys := s.tags[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
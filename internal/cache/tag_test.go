@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGroup_InvalidateTag(t *testing.T) {
+	e := NewEngine()
+	e.AddGroup("tags", nil, 1<<10, WithSweepInterval(0))
+	g := e.GetGroup("tags")
+
+	g.AddWithTTL("a", NewByteView([]byte("a")), 0, "p1")
+	g.AddWithTTL("b", NewByteView([]byte("b")), 0, "p1", "p2")
+	g.AddWithTTL("c", NewByteView([]byte("c")), 0, "p2")
+	g.Add("d", NewByteView([]byte("d")))
+
+	if n, err := g.InvalidateTag("p1"); err != nil || n != 2 {
+		t.Fatalf("expected 2 entries removed, got %d %v", n, err)
+	}
+	for _, key := range []string{"a", "b"} {
+		if _, err := g.Get(key); err != ErrKeyNotFound {
+			t.Errorf("expected %s to be invalidated, got %v", key, err)
+		}
+	}
+	for _, key := range []string{"c", "d"} {
+		if _, err := g.Get(key); err != nil {
+			t.Errorf("expected %s to be kept, got %v", key, err)
+		}
+	}
+	if tags, keys := g.cache.tagCount(); tags != 1 || keys != 1 {
+		t.Errorf("expected only p2 -> c to remain indexed, got %d tags %d keys", tags, keys)
+	}
+	if _, err := g.InvalidateTag(""); err != ErrTagIsNil {
+		t.Errorf("expected ErrTagIsNil, got %v", err)
+	}
+}
+
+func TestGroup_InvalidateTagAfterRefresh(t *testing.T) {
+	getter := &countingGetter{}
+	e := NewEngine()
+	e.AddGroup("tags-refresh", getter, 1<<10, WithSweepInterval(0), WithStaleWhileRevalidate(time.Hour))
+	g := e.GetGroup("tags-refresh")
+
+	g.AddWithTTL("k", NewByteView([]byte("k-v0")), time.Millisecond, "p1")
+	time.Sleep(5 * time.Millisecond)
+	// 过期后返回旧数据并在后台刷新
+	if v, err := g.Get("k"); err != nil || v.String() != "k-v0" {
+		t.Fatalf("expected stale value, got %q %v", v.String(), err)
+	}
+	waitFor(t, func() bool {
+		v, _ := g.cache.lookup("k")
+		return v != nil && v.value.String() == "k-v1"
+	})
+
+	if n, err := g.InvalidateTag("p1"); err != nil || n != 1 {
+		t.Fatalf("expected the refreshed entry to keep its tag, got %d %v", n, err)
+	}
+	if _, ok := g.cache.lookup("k"); ok {
+		t.Error("expected the refreshed entry to be invalidated")
+	}
+}
+
+func TestCache_TagIndexCleanup(t *testing.T) {
+	c := newTestCache(1, 200)
+	for i := range 50 {
+		key := fmt.Sprintf("k%02d", i)
+		c.add(key, NewByteView([]byte(key)), time.Time{}, "all", key)
+	}
+	// 被lru淘汰的条目不再保留在索引中
+	if tags, keys := c.tagCount(); keys != c.len() || tags != c.len()+1 {
+		t.Errorf("expected index to match %d live entries, got %d tags %d keys", c.len(), tags, keys)
+	}
+
+	// 覆盖为不带标签的值、删除和过期清理都会清除索引
+	c.add("k49", NewByteView([]byte("x")), time.Time{})
+	c.remove("k48")
+	c.add("k47", NewByteView([]byte("x")), time.Now().Add(-time.Second), "all")
+	c.removeExpired()
+	live := c.len()
+	if n := c.removeTag("all"); n != live-1 || c.len() != 1 {
+		t.Errorf("expected all entries but k49 removed, got %d of %d", n, live)
+	}
+	if tags, keys := c.tagCount(); tags != 0 || keys != 0 {
+		t.Errorf("expected empty index, got %d tags %d keys", tags, keys)
+	}
+}
+
+// tagCount 返回标签索引中的标签数和带标签的key数
+func (c *cache) tagCount() (int, int) {
+	tags, keys := 0, 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		tags += len(s.tags)
+		keys += len(s.tagged)
+		s.mu.Unlock()
+	}
+	return tags, keys
+}
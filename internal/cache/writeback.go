@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type SetterFunc func(string, []byte) error
+
+// Setter 将写入同步到数据源
+type Setter interface {
+	Set(key string, value []byte) error
+}
+
+func (f SetterFunc) Set(key string, value []byte) error {
+	return f(key, value)
+}
+
+type DeleterFunc func(string) error
+
+// Deleter 将删除同步到数据源
+type Deleter interface {
+	Delete(key string) error
+}
+
+func (f DeleterFunc) Delete(key string) error {
+	return f(key)
+}
+
+var (
+	// ErrWriteQueueFull write-behind队列已满
+	ErrWriteQueueFull = errors.New("WriteQueueFull")
+	// ErrWriteQueueClosed Group已关闭，不再接受写入
+	ErrWriteQueueClosed = errors.New("WriteQueueClosed")
+)
+
+const (
+	// defaultWriteBackoff 写入数据源失败后第一次重试前的等待时间，之后每次翻倍
+	defaultWriteBackoff = 100 * time.Millisecond
+	// maxWriteBackoff 重试等待时间的上限
+	maxWriteBackoff = 5 * time.Second
+)
+
+// WithWriteThrough 写入和删除时同步写入数据源，数据源返回错误时不更新缓存，setter或deleter为空时不同步对应的操作
+func WithWriteThrough(setter Setter, deleter Deleter) GroupOption {
+	return func(g *Group) {
+		g.setter = setter
+		g.deleter = deleter
+		g.writeQueue = nil
+	}
+}
+
+// WithWriteBehind 写入和删除立即更新缓存，再由后台按顺序写入数据源。
+// 队列中同一个key的多次写入只保留最后一次，排队的key达到queueSize时返回ErrWriteQueueFull，
+// 写入失败时按指数退避最多重试maxRetries次，Close时会写完队列中剩余的数据
+func WithWriteBehind(setter Setter, deleter Deleter, queueSize int, maxRetries int) GroupOption {
+	return func(g *Group) {
+		g.setter = setter
+		g.deleter = deleter
+		g.writeQueue = newWriteQueue(setter, deleter, queueSize, maxRetries)
+	}
+}
+
+// storeSet 将写入同步到数据源，开启write-behind时加入写队列
+func (g *Group) storeSet(key string, value ByteView) error {
+	if g.writeQueue != nil {
+		return g.writeQueue.push(key, writeOp{value: value})
+	}
+	if g.setter == nil {
+		return nil
+	}
+	return g.setter.Set(key, value.ByteSlices())
+}
+
+// storeSetAfterWrite 写入本地缓存成功后再写入数据源，写入数据源失败时删除缓存，避免缓存与数据源不一致，
+// 用于需要先在缓存中比较或计算的写入
+func (g *Group) storeSetAfterWrite(key string, value ByteView) error {
+	if err := g.storeSet(key, value); err != nil {
+		g.cache.remove(key)
+		return err
+	}
+	return nil
+}
+
+// storeDelete 将删除同步到数据源，开启write-behind时加入写队列
+func (g *Group) storeDelete(key string) error {
+	if g.writeQueue != nil {
+		return g.writeQueue.push(key, writeOp{delete: true})
+	}
+	if g.deleter == nil {
+		return nil
+	}
+	return g.deleter.Delete(key)
+}
+
+// PendingWrites 返回write-behind队列中等待写入数据源的key数
+func (g *Group) PendingWrites() int {
+	if g.writeQueue == nil {
+		return 0
+	}
+	return g.writeQueue.len()
+}
+
+// WriteFailures 返回重试后仍未能写入数据源而被丢弃的写入数
+func (g *Group) WriteFailures() int64 {
+	if g.writeQueue == nil {
+		return 0
+	}
+	return g.writeQueue.failures.Load()
+}
+
+// writeOp 等待写入数据源的操作
+type writeOp struct {
+	value  ByteView
+	delete bool
+}
+
+// writeQueue write-behind队列，按key第一次入队的顺序由一个后台goroutine写入数据源
+type writeQueue struct {
+	setter     Setter
+	deleter    Deleter
+	size       int
+	maxRetries int
+	backoff    time.Duration
+
+	mutex   sync.Mutex
+	pending map[string]writeOp // 每个key最后一次的写入
+	keys    []string           // 等待写入的key，按入队顺序
+	closed  bool
+	wake    chan struct{}
+	done    chan struct{}
+
+	failures atomic.Int64
+}
+
+func newWriteQueue(setter Setter, deleter Deleter, size int, maxRetries int) *writeQueue {
+	return &writeQueue{
+		setter:     setter,
+		deleter:    deleter,
+		size:       max(size, 1),
+		maxRetries: max(maxRetries, 0),
+		backoff:    defaultWriteBackoff,
+		pending:    make(map[string]writeOp),
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// push 加入写队列，key已在队列中时覆盖之前的写入
+func (q *writeQueue) push(key string, op writeOp) error {
+	q.mutex.Lock()
+	if q.closed {
+		q.mutex.Unlock()
+		return ErrWriteQueueClosed
+	}
+	if _, ok := q.pending[key]; !ok {
+		if len(q.keys) >= q.size {
+			q.mutex.Unlock()
+			return ErrWriteQueueFull
+		}
+		q.keys = append(q.keys, key)
+	}
+	q.pending[key] = op
+	q.mutex.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pop 取出最早入队的key，队列为空时返回false
+func (q *writeQueue) pop() (string, writeOp, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.keys) == 0 {
+		return "", writeOp{}, false
+	}
+	key := q.keys[0]
+	q.keys = q.keys[1:]
+	op := q.pending[key]
+	delete(q.pending, key)
+	return key, op, true
+}
+
+// queued 判断key是否有新的写入在排队
+func (q *writeQueue) queued(key string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	_, ok := q.pending[key]
+	return ok
+}
+
+func (q *writeQueue) len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.keys)
+}
+
+// run 依次写入数据源，关闭后写完剩余的数据再退出
+func (q *writeQueue) run() {
+	defer close(q.done)
+	for {
+		key, op, ok := q.pop()
+		if ok {
+			q.write(key, op)
+			continue
+		}
+		q.mutex.Lock()
+		closed := q.closed
+		q.mutex.Unlock()
+		if closed {
+			return
+		}
+		<-q.wake
+	}
+}
+
+// write 写入数据源，失败时按指数退避重试，同一个key有新的写入排队时放弃重试
+func (q *writeQueue) write(key string, op writeOp) {
+	backoff := q.backoff
+	for attempt := 0; ; attempt++ {
+		err := q.apply(key, op)
+		if err == nil {
+			return
+		}
+		if q.queued(key) {
+			return
+		}
+		if attempt >= q.maxRetries {
+			q.failures.Add(1)
+			log.Printf("write-behind %s: %v, dropped after %d attempts", key, err, attempt+1)
+			return
+		}
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxWriteBackoff)
+	}
+}
+
+func (q *writeQueue) apply(key string, op writeOp) error {
+	if op.delete {
+		if q.deleter == nil {
+			return nil
+		}
+		return q.deleter.Delete(key)
+	}
+	if q.setter == nil {
+		return nil
+	}
+	return q.setter.Set(key, op.value.ByteSlices())
+}
+
+// close 停止接受写入，等待队列中剩余的数据写入数据源
+func (q *writeQueue) close() {
+	q.mutex.Lock()
+	q.closed = true
+	q.mutex.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	<-q.done
+}
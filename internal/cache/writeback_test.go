@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testStore 记录写入数据源的数据，fail非零时前fail次写入返回错误
+type testStore struct {
+	mutex  sync.Mutex
+	data   map[string]string
+	writes int
+	fail   int
+	block  chan struct{} // 非空时写入等待该channel关闭
+}
+
+func newTestStore() *testStore {
+	return &testStore{data: make(map[string]string)}
+}
+
+func (s *testStore) Set(key string, value []byte) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.writes++
+	if s.fail > 0 {
+		s.fail--
+		return errors.New("store unavailable")
+	}
+	s.data[key] = string(value)
+	return nil
+}
+
+func (s *testStore) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.writes++
+	delete(s.data, key)
+	return nil
+}
+
+func (s *testStore) snapshot() (map[string]string, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data := make(map[string]string, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+	return data, s.writes
+}
+
+func TestGroup_WriteThrough(t *testing.T) {
+	store := newTestStore()
+	e := NewEngine()
+	e.AddGroup("wt", nil, 1<<10, WithSweepInterval(0), WithWriteThrough(store, store))
+	g := e.GetGroup("wt")
+
+	if err := g.Add("k", NewByteView([]byte("v"))); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := store.snapshot(); data["k"] != "v" {
+		t.Errorf("expected write to reach the store, got %v", data)
+	}
+
+	store.fail = 1
+	if err := g.Add("k", NewByteView([]byte("v2"))); err == nil {
+		t.Error("expected store error to be returned")
+	}
+	if v, _ := g.GetLocal("k"); v.String() != "v" {
+		t.Errorf("expected failed write to leave cache unchanged, got %q", v.String())
+	}
+
+	if err := g.Delete("k"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := store.snapshot(); len(data) != 0 {
+		t.Errorf("expected delete to reach the store, got %v", data)
+	}
+}
+
+// groupPeer 将转发来的请求交给另一个Group的Local方法处理，模拟key所属的远程节点
+type groupPeer struct {
+	g *Group
+}
+
+func (p groupPeer) Get(group string, key string) ([]byte, error) {
+	v, err := p.g.GetLocal(key)
+	return v.ByteSlices(), err
+}
+
+func (p groupPeer) Set(group string, key string, value []byte, ttl time.Duration) error {
+	return p.g.AddLocal(key, NewByteView(value), ttl)
+}
+
+func (p groupPeer) Delete(group string, key string) error {
+	return p.g.DeleteLocal(key)
+}
+
+func TestGroup_WriteThroughOnOwner(t *testing.T) {
+	senderStore, ownerStore := newTestStore(), newTestStore()
+	e := NewEngine()
+	e.AddGroup("owner", nil, 1<<10, WithSweepInterval(0), WithWriteThrough(ownerStore, ownerStore))
+	owner := e.GetGroup("owner")
+	e.AddGroup("sender", nil, 1<<10, WithSweepInterval(0), WithWriteThrough(senderStore, senderStore),
+		WithPeersPicker(testPicker{groupPeer{owner}}))
+	sender := e.GetGroup("sender")
+
+	if err := sender.Add("k", NewByteView([]byte("v"))); err != nil {
+		t.Fatal(err)
+	}
+	if failed := sender.SetMany(map[string]ByteView{"m": NewByteView([]byte("v"))}, 0); len(failed) != 0 {
+		t.Fatalf("expected batch write to succeed, got %v", failed)
+	}
+	if data, _ := ownerStore.snapshot(); data["k"] != "v" || data["m"] != "v" {
+		t.Errorf("expected writes to reach the store through the owner, got %v", data)
+	}
+
+	// 数据源写入失败时key所属节点的缓存保持不变
+	ownerStore.fail = 1
+	if err := sender.Add("k", NewByteView([]byte("v2"))); err == nil {
+		t.Error("expected the owner's store error to be returned")
+	}
+	if v, _ := owner.GetLocal("k"); v.String() != "v" {
+		t.Errorf("expected the owner's cache to match the store, got %q", v.String())
+	}
+
+	if err := sender.Delete("k"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ownerStore.snapshot(); data["k"] != "" {
+		t.Errorf("expected delete to reach the store through the owner, got %v", data)
+	}
+	if _, writes := senderStore.snapshot(); writes != 0 {
+		t.Errorf("expected the receiving node not to write the store, got %d writes", writes)
+	}
+}
+
+func TestGroup_WriteBehindCoalesces(t *testing.T) {
+	store := newTestStore()
+	store.block = make(chan struct{})
+	e := NewEngine()
+	e.AddGroup("wb", nil, 1<<10, WithSweepInterval(0), WithWriteBehind(store, store, 2, 0))
+	g := e.GetGroup("wb")
+
+	// 第一次写入被后台取出后阻塞，之后的写入在队列中合并
+	g.Add("a", NewByteView([]byte("1")))
+	waitFor(t, func() bool { return g.PendingWrites() == 0 })
+	for _, v := range []string{"2", "3", "4"} {
+		if err := g.Add("a", NewByteView([]byte(v))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.Add("b", NewByteView([]byte("1"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Add("c", NewByteView([]byte("1"))); err != ErrWriteQueueFull {
+		t.Errorf("expected ErrWriteQueueFull, got %v", err)
+	}
+	if v, _ := g.GetLocal("a"); v.String() != "4" {
+		t.Errorf("expected cache to be updated immediately, got %q", v.String())
+	}
+
+	close(store.block)
+	g.Close()
+	data, writes := store.snapshot()
+	if data["a"] != "4" || data["b"] != "1" {
+		t.Errorf("expected queued writes to be flushed on close, got %v", data)
+	}
+	if writes != 3 {
+		t.Errorf("expected repeated writes to be coalesced into 3 store writes, got %d", writes)
+	}
+	if err := g.Add("d", NewByteView([]byte("1"))); err != ErrWriteQueueClosed {
+		t.Errorf("expected ErrWriteQueueClosed after close, got %v", err)
+	}
+}
+
+func TestGroup_WriteBehindRetry(t *testing.T) {
+	store := newTestStore()
+	store.fail = 2
+	e := NewEngine()
+	e.AddGroup("retry", nil, 1<<10, WithSweepInterval(0), WithWriteBehind(store, store, 10, 2))
+	g := e.GetGroup("retry")
+	g.writeQueue.backoff = time.Millisecond
+
+	g.Add("k", NewByteView([]byte("v")))
+	g.Close()
+	if data, writes := store.snapshot(); data["k"] != "v" || writes != 3 {
+		t.Errorf("expected write to succeed on the third attempt, got %v after %d writes", data, writes)
+	}
+	if g.WriteFailures() != 0 {
+		t.Errorf("expected no dropped writes, got %d", g.WriteFailures())
+	}
+}
+
+func TestEngine_ReplaceGroupDoesNotBlock(t *testing.T) {
+	store := newTestStore()
+	store.block = make(chan struct{})
+	e := NewEngine()
+	e.AddGroup("wb-replace", nil, 1<<10, WithSweepInterval(0), WithWriteBehind(store, store, 10, 0))
+	e.AddGroup("other", nil, 1<<10, WithSweepInterval(0))
+	e.GetGroup("wb-replace").Add("k", NewByteView([]byte("v")))
+
+	replaced := make(chan struct{})
+	go func() {
+		defer close(replaced)
+		e.ReplaceGroup("wb-replace", nil, 1<<10, WithSweepInterval(0))
+	}()
+	// 旧Group等待数据源写入期间，其他请求仍然可以访问Engine
+	swapped := make(chan bool, 1)
+	go func() {
+		for {
+			if g := e.GetGroup("wb-replace"); g.writeQueue == nil {
+				swapped <- e.GetGroup("other") != nil
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	select {
+	case ok := <-swapped:
+		if !ok {
+			t.Fatal("expected other groups to stay reachable")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("engine was blocked while the old group was closing")
+	}
+	select {
+	case <-replaced:
+		t.Fatal("expected replace to wait for the old write-behind queue")
+	default:
+	}
+	close(store.block)
+	<-replaced
+	if store.data["k"] != "v" {
+		t.Errorf("expected the old queue to be drained, got %v", store.data)
+	}
+}
@@ -13,6 +13,8 @@ type Config struct {
 	HTTP HTTPConfig `json:"http"`
 	// 一致性哈希配置
 	Hash HashConfig `json:"hash"`
+	// 集群配置
+	Cluster ClusterConfig `json:"cluster"`
 }
 
 // CacheConfig 缓存相关配置
@@ -21,6 +23,68 @@ type CacheConfig struct {
 	MaxEntries int `json:"maxEntries"`
 	// 最大缓存容量（字节）
 	MaxBytes int64 `json:"maxBytes"`
+	// 默认过期时间（毫秒），0表示永不过期
+	TTLMs int64 `json:"ttlMs"`
+	// 默认淘汰策略：lru、lfu、tinylfu
+	Policy string `json:"policy"`
+	// 所有Group的总容量上限（字节），0表示不限制，此时maxBytes为每个Group最多分到的容量
+	MaxTotalBytes int64 `json:"maxTotalBytes"`
+	// 重新分配各Group容量的间隔（毫秒），0表示使用默认值
+	RebalanceIntervalMs int64 `json:"rebalanceIntervalMs"`
+	// 自动创建的、未在groups中声明的Group的最大数量，0表示不限制
+	MaxGroups int `json:"maxGroups"`
+	// 按Group名称覆盖的配置
+	Groups map[string]GroupConfig `json:"groups"`
+}
+
+// GroupConfig 单个Group的配置，零值字段使用CacheConfig中的默认值
+type GroupConfig struct {
+	// 淘汰策略：lru、lfu、tinylfu
+	Policy string `json:"policy"`
+	// 最大缓存条目数
+	MaxEntries int `json:"maxEntries"`
+	// 默认过期时间（毫秒）
+	TTLMs int64 `json:"ttlMs"`
+	// 过期后仍返回旧数据并在后台刷新的时间窗口（毫秒），0表示不开启
+	StaleWhileRevalidateMs int64 `json:"staleWhileRevalidateMs"`
+	// 距离过期小于该时间（毫秒）时提前刷新热点数据，0表示不开启
+	RefreshAheadMs int64 `json:"refreshAheadMs"`
+	// 提前刷新要求的最少命中次数
+	RefreshAheadMinHits int64 `json:"refreshAheadMinHits"`
+	// 后台刷新的最大并发数
+	MaxConcurrentRefreshes int `json:"maxConcurrentRefreshes"`
+	// 回源未找到时的负缓存时间（毫秒），0表示不缓存
+	NotFoundTTLMs int64 `json:"notFoundTtlMs"`
+	// 回源出错时的负缓存时间（毫秒），0表示不缓存
+	ErrorTTLMs int64 `json:"errorTtlMs"`
+	// 热点缓存配置，为空时不开启
+	HotCache *HotCacheConfig `json:"hotCache"`
+	// 压缩配置，为空时不压缩
+	Compression *CompressionConfig `json:"compression"`
+	// 配置了总容量上限时至少分到的容量（字节）
+	MinBytes int64 `json:"minBytes"`
+	// 配置了总容量上限时分配剩余容量的权重，0表示使用默认值1
+	Weight int `json:"weight"`
+}
+
+// CompressionConfig 压缩配置，值按压缩后的大小计入容量
+type CompressionConfig struct {
+	// 压缩算法：flate、gzip
+	Codec string `json:"codec"`
+	// 压缩级别，1到9与compress/flate相同，0或-1表示默认级别
+	Level int `json:"level"`
+	// 小于该字节数的值不压缩
+	MinBytes int `json:"minBytes"`
+}
+
+// HotCacheConfig 热点缓存配置，保存从远程节点获取的数据副本
+type HotCacheConfig struct {
+	// 最大容量（字节）
+	MaxBytes int64 `json:"maxBytes"`
+	// 副本的过期时间（毫秒）
+	TTLMs int64 `json:"ttlMs"`
+	// 从远程节点获取的数据被缓存的比例，取值(0, 1]
+	SampleRate float64 `json:"sampleRate"`
 }
 
 // HTTPConfig HTTP服务器配置
@@ -37,11 +101,29 @@ type HashConfig struct {
 	Replicas int `json:"replicas"`
 }
 
+// ClusterConfig 集群配置，Peers为空时以单机模式运行
+type ClusterConfig struct {
+	// 本节点对外的地址，如 192.168.1.134:8001，需与Peers中的写法一致
+	Self string `json:"self"`
+	// 集群中所有节点的地址，未包含Self时会自动加入
+	Peers []string `json:"peers"`
+	// 节点间通信的协议：http、https
+	Scheme string `json:"scheme"`
+	// 一致性哈希函数：sha1、crc32、fnv，集群中所有节点必须一致
+	HashFunc string `json:"hashFunc"`
+	// 节点间请求的超时时间（毫秒）
+	PeerTimeoutMs int64 `json:"peerTimeoutMs"`
+	// 与每个节点保持的最大空闲连接数
+	MaxIdleConnsPerPeer int `json:"maxIdleConnsPerPeer"`
+}
+
 // DefaultConfig 默认配置
 var DefaultConfig = Config{
 	Cache: CacheConfig{
 		MaxEntries: 1000,
 		MaxBytes:   1 << 20, // 默认1MB
+		Policy:     "lru",
+		MaxGroups:  64,
 	},
 	HTTP: HTTPConfig{
 		Address: "0.0.0.0",
@@ -50,6 +132,12 @@ var DefaultConfig = Config{
 	Hash: HashConfig{
 		Replicas: 50,
 	},
+	Cluster: ClusterConfig{
+		Scheme:              "http",
+		HashFunc:            "sha1",
+		PeerTimeoutMs:       3000,
+		MaxIdleConnsPerPeer: 64,
+	},
 }
 
 // LoadConfig 从文件加载配置
@@ -1,7 +1,11 @@
 package consistenthash
 
 import (
+	"crypto/sha1"
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
+	"hash/fnv"
 	"slices"
 	"zencache/internal/config"
 )
@@ -9,6 +13,32 @@ import (
 // Hash 是一个哈希函数类型，接受 []byte 并返回 uint32 哈希值。
 type Hash func([]byte) uint32
 
+// hashes 可通过名称选择的哈希函数。
+var hashes = map[string]Hash{
+	"sha1": func(b []byte) uint32 {
+		hash := sha1.Sum(b)
+		return binary.LittleEndian.Uint32(hash[:4])
+	},
+	"crc32": crc32.ChecksumIEEE,
+	"fnv": func(b []byte) uint32 {
+		h := fnv.New32a()
+		h.Write(b)
+		return h.Sum32()
+	},
+}
+
+// HashByName 根据名称返回哈希函数，名称为空时使用 sha1。
+func HashByName(name string) (Hash, error) {
+	if name == "" {
+		name = "sha1"
+	}
+	hash, ok := hashes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash function: %s", name)
+	}
+	return hash, nil
+}
+
 // Map 表示一致性哈希地图。
 type Map struct {
 	hash    Hash           // 哈希函数
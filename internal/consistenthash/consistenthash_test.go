@@ -47,3 +47,18 @@ func TestHashing(t *testing.T) {
 	}
 
 }
+
+func TestHashByName(t *testing.T) {
+	for _, name := range []string{"", "sha1", "crc32", "fnv"} {
+		hash, err := HashByName(name)
+		if err != nil {
+			t.Fatalf("HashByName(%q): %v", name, err)
+		}
+		if hash([]byte("key")) != hash([]byte("key")) {
+			t.Errorf("hash %q is not deterministic", name)
+		}
+	}
+	if _, err := HashByName("md5"); err == nil {
+		t.Error("expected error for unknown hash function")
+	}
+}
@@ -0,0 +1,31 @@
+package eviction
+
+import "time"
+
+// Value 缓存值，Len返回其占用的字节数
+type Value interface {
+	Len() int
+}
+
+// Policy 淘汰策略，实现不需要保证并发安全，由调用方加锁
+type Policy interface {
+	// Get 获取缓存值，已过期的条目应被删除
+	Get(key string) (Value, bool)
+	// AddWithExpire 添加条目，expire为零值表示永不过期
+	AddWithExpire(key string, value Value, expire time.Time)
+	// Remove 删除指定条目并触发淘汰回调，返回条目是否存在
+	Remove(key string) bool
+	// RemoveExpired 删除所有已过期的条目，返回删除的数量
+	RemoveExpired() int
+	// Resize 调整容量，超出新容量的条目按策略淘汰并触发淘汰回调
+	Resize(maxEntries int, maxBytes int64)
+	// Evict 按策略淘汰一个key不为keep的条目并触发淘汰回调，没有可淘汰的条目时返回false
+	Evict(keep string) bool
+	// Len 返回条目数量
+	Len() int
+	// Bytes 返回当前占用的字节数
+	Bytes() int64
+}
+
+// Factory 创建淘汰策略实例，maxEntries为0表示不限制条目数，onEvicted在条目被淘汰时调用
+type Factory func(maxEntries int, maxBytes int64, onEvicted func(key string, value Value)) Policy
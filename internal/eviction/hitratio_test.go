@@ -0,0 +1,127 @@
+package eviction_test
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"zencache/internal/eviction"
+	"zencache/internal/lfu"
+	"zencache/internal/lru"
+	"zencache/internal/tinylfu"
+)
+
+type testValue struct {
+	size int
+}
+
+func (v testValue) Len() int { return v.size }
+
+const valueSize = 64
+
+var policies = map[string]eviction.Factory{
+	"lru": func(maxEntries int, maxBytes int64, onEvicted func(string, eviction.Value)) eviction.Policy {
+		return lru.NewWithMaxEntries(maxEntries, maxBytes, onEvicted)
+	},
+	"lfu": func(maxEntries int, maxBytes int64, onEvicted func(string, eviction.Value)) eviction.Policy {
+		return lfu.NewWithMaxEntries(maxEntries, maxBytes, onEvicted)
+	},
+	"tinylfu": func(maxEntries int, maxBytes int64, onEvicted func(string, eviction.Value)) eviction.Policy {
+		return tinylfu.NewWithMaxEntries(maxEntries, maxBytes, onEvicted)
+	},
+}
+
+// zipfTrace 生成服从zipf分布的访问序列
+func zipfTrace(n int, keys uint64, seed int64) []string {
+	r := rand.New(rand.NewSource(seed))
+	z := rand.NewZipf(r, 1.1, 1, keys-1)
+	trace := make([]string, n)
+	for i := range trace {
+		trace[i] = fmt.Sprintf("z%d", z.Uint64())
+	}
+	return trace
+}
+
+// scanTrace 在zipf访问中周期性插入一次性的全量扫描，模拟夜间批处理任务
+func scanTrace(n int, keys uint64, scanLen int, seed int64) []string {
+	base := zipfTrace(n, keys, seed)
+	trace := make([]string, 0, n+n/scanLen*scanLen)
+	scan := 0
+	for i, k := range base {
+		trace = append(trace, k)
+		if i > 0 && i%(scanLen*2) == 0 {
+			for j := 0; j < scanLen; j++ {
+				trace = append(trace, fmt.Sprintf("s%d", scan))
+				scan++
+			}
+		}
+	}
+	return trace
+}
+
+// loadTraces 读取testdata目录下录制的访问序列，每行一个key
+func loadTraces(b *testing.B) map[string][]string {
+	traces := make(map[string][]string)
+	files, err := filepath.Glob(filepath.Join("testdata", "*.trace"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var trace []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			trace = append(trace, scanner.Text())
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			b.Fatal(err)
+		}
+		traces[filepath.Base(file)] = trace
+	}
+	return traces
+}
+
+// hitRatio 按访问序列回放，未命中时写入缓存
+func hitRatio(p eviction.Policy, trace []string) float64 {
+	hits := 0
+	for _, k := range trace {
+		if _, ok := p.Get(k); ok {
+			hits++
+		} else {
+			p.AddWithExpire(k, testValue{valueSize}, zeroTime)
+		}
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+func BenchmarkHitRatio(b *testing.B) {
+	traces := map[string][]string{
+		"zipf": zipfTrace(200000, 50000, 1),
+		"scan": scanTrace(200000, 50000, 5000, 1),
+	}
+	for name, trace := range loadTraces(b) {
+		traces[name] = trace
+	}
+	// 缓存容量约为1000个条目
+	maxBytes := int64(1000 * (valueSize + 8))
+	for traceName, trace := range traces {
+		for policyName, factory := range policies {
+			b.Run(traceName+"/"+policyName, func(b *testing.B) {
+				var ratio float64
+				for range b.N {
+					ratio = hitRatio(factory(0, maxBytes, nil), trace)
+				}
+				b.ReportMetric(ratio*100, "hit%")
+			})
+		}
+	}
+}
+
+var zeroTime time.Time
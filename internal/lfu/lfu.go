@@ -0,0 +1,211 @@
+package lfu
+
+import (
+	"container/heap"
+	"time"
+	"zencache/internal/eviction"
+)
+
+// Cache 最不经常使用淘汰策略，访问次数相同时淘汰最久未访问的条目
+type Cache struct {
+	maxEntries int // 最大条目数，0表示不限制
+	maxBytes   int64
+	nBytes     int64
+	tick       uint64 // 逻辑时钟，用于区分访问次数相同的条目
+	heap       entryHeap
+	cache      map[string]*entry
+	onEvicted  func(key string, value eviction.Value)
+}
+
+var _ eviction.Policy = (*Cache)(nil)
+
+func New(maxBytes int64, onEvicted func(key string, value eviction.Value)) *Cache {
+	return NewWithMaxEntries(0, maxBytes, onEvicted)
+}
+
+// NewWithMaxEntries 创建同时限制条目数和字节数的缓存，maxEntries为0表示不限制条目数
+func NewWithMaxEntries(maxEntries int, maxBytes int64, onEvicted func(key string, value eviction.Value)) *Cache {
+	return &Cache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		cache:      make(map[string]*entry),
+		onEvicted:  onEvicted,
+	}
+}
+
+type entry struct {
+	key    string
+	value  eviction.Value
+	expire time.Time // 过期时间，零值表示永不过期
+	freq   uint64    // 访问次数
+	tick   uint64    // 最近一次访问的逻辑时间
+	index  int       // 在堆中的位置
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expire.IsZero() && now.After(e.expire)
+}
+
+// Get 获取缓存值并增加访问次数，已过期的条目会被惰性删除
+func (c *Cache) Get(key string) (eviction.Value, bool) {
+	e, ok := c.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if e.expired(time.Now()) {
+		c.removeEntry(e)
+		return nil, false
+	}
+	c.touch(e)
+	return e.value, true
+}
+
+// Add 添加永不过期的条目
+func (c *Cache) Add(key string, value eviction.Value) {
+	c.AddWithExpire(key, value, time.Time{})
+}
+
+// AddWithExpire 添加条目并指定过期时间，零值表示永不过期
+func (c *Cache) AddWithExpire(key string, value eviction.Value, expire time.Time) {
+	if e, ok := c.cache[key]; ok {
+		c.nBytes += int64(value.Len()) - int64(e.value.Len())
+		e.value = value
+		e.expire = expire
+		c.touch(e)
+	} else {
+		c.tick++
+		e = &entry{
+			key:    key,
+			value:  value,
+			expire: expire,
+			freq:   1,
+			tick:   c.tick,
+		}
+		heap.Push(&c.heap, e)
+		c.cache[key] = e
+		c.nBytes += int64(len(key) + value.Len())
+	}
+	for c.Len() > 0 && c.overflow() {
+		c.RemoveLeastFrequent()
+	}
+}
+
+// overflow 判断是否超出字节数或条目数限制
+func (c *Cache) overflow() bool {
+	return c.nBytes > c.maxBytes || (c.maxEntries > 0 && c.Len() > c.maxEntries)
+}
+
+// Remove 删除指定条目，返回条目是否存在
+func (c *Cache) Remove(key string) bool {
+	e, ok := c.cache[key]
+	if !ok {
+		return false
+	}
+	c.removeEntry(e)
+	return true
+}
+
+// Resize 调整容量，超出新容量时淘汰访问次数最少的条目
+func (c *Cache) Resize(maxEntries int, maxBytes int64) {
+	c.maxEntries = maxEntries
+	c.maxBytes = maxBytes
+	for c.Len() > 0 && c.overflow() {
+		c.RemoveLeastFrequent()
+	}
+}
+
+// Evict 淘汰访问次数最少且key不为keep的条目，堆顶为keep时从它的两个子节点中选择
+func (c *Cache) Evict(keep string) bool {
+	if c.heap.Len() == 0 {
+		return false
+	}
+	if c.heap[0].key != keep {
+		c.removeEntry(c.heap[0])
+		return true
+	}
+	victim := -1
+	for _, i := range []int{1, 2} {
+		if i < c.heap.Len() && (victim < 0 || c.heap.Less(i, victim)) {
+			victim = i
+		}
+	}
+	if victim < 0 {
+		return false
+	}
+	c.removeEntry(c.heap[victim])
+	return true
+}
+
+// RemoveLeastFrequent 淘汰访问次数最少的条目
+func (c *Cache) RemoveLeastFrequent() {
+	if c.heap.Len() > 0 {
+		c.removeEntry(c.heap[0])
+	}
+}
+
+// RemoveExpired 删除所有已过期的条目，返回删除的数量
+func (c *Cache) RemoveExpired() int {
+	now := time.Now()
+	removed := 0
+	for _, e := range c.cache {
+		if e.expired(now) {
+			c.removeEntry(e)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *Cache) Len() int {
+	return len(c.cache)
+}
+
+// Bytes 返回当前占用的字节数
+func (c *Cache) Bytes() int64 {
+	return c.nBytes
+}
+
+func (c *Cache) touch(e *entry) {
+	c.tick++
+	e.freq++
+	e.tick = c.tick
+	heap.Fix(&c.heap, e.index)
+}
+
+func (c *Cache) removeEntry(e *entry) {
+	heap.Remove(&c.heap, e.index)
+	delete(c.cache, e.key)
+	c.nBytes -= int64(len(e.key) + e.value.Len())
+	if c.onEvicted != nil {
+		c.onEvicted(e.key, e.value)
+	}
+}
+
+// entryHeap 按访问次数和访问时间排序的小顶堆
+type entryHeap []*entry
+
+func (h entryHeap) Len() int { return len(h) }
+func (h entryHeap) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].tick < h[j].tick
+}
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *entryHeap) Push(x any) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *entryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
@@ -0,0 +1,89 @@
+package lfu
+
+import (
+	"testing"
+	"time"
+	"zencache/internal/eviction"
+)
+
+type testValue struct {
+	size int
+}
+
+func (v testValue) Len() int { return v.size }
+
+func TestCache_EvictLeastFrequent(t *testing.T) {
+	// 每个条目大小: len(key)=2, value.Len=5 → 总大小7
+	c := New(14, nil)
+	c.Add("k1", testValue{5})
+	c.Add("k2", testValue{5})
+
+	// k1被访问更多次
+	c.Get("k1")
+	c.Get("k1")
+
+	c.Add("k3", testValue{5}) // 淘汰访问次数最少的k2
+
+	if _, ok := c.Get("k2"); ok {
+		t.Error("k2 should have been evicted")
+	}
+	if _, ok := c.Get("k1"); !ok {
+		t.Error("k1 should not have been evicted")
+	}
+	if c.Len() != 2 || c.Bytes() != 14 {
+		t.Errorf("expected 2 items of 14 bytes, got %d items of %d bytes", c.Len(), c.Bytes())
+	}
+}
+
+func TestCache_TieBreaksByRecency(t *testing.T) {
+	c := New(14, nil)
+	c.Add("k1", testValue{5})
+	c.Add("k2", testValue{5})
+	c.Add("k3", testValue{5}) // 访问次数相同，淘汰最早的k1
+
+	if _, ok := c.Get("k1"); ok {
+		t.Error("k1 should have been evicted")
+	}
+}
+
+func TestCache_Expire(t *testing.T) {
+	var evicted []string
+	c := New(100, func(key string, value eviction.Value) {
+		evicted = append(evicted, key)
+	})
+	c.AddWithExpire("k1", testValue{5}, time.Now().Add(-time.Millisecond))
+	c.AddWithExpire("k2", testValue{5}, time.Now().Add(-time.Millisecond))
+	c.Add("k3", testValue{5})
+
+	if _, ok := c.Get("k1"); ok {
+		t.Error("k1 should have expired")
+	}
+	if n := c.RemoveExpired(); n != 1 {
+		t.Errorf("expected 1 expired item, got %d", n)
+	}
+	if c.Len() != 1 || c.Bytes() != 7 || len(evicted) != 2 {
+		t.Errorf("unexpected state: %d items, %d bytes, evicted %v", c.Len(), c.Bytes(), evicted)
+	}
+}
+
+func TestCache_Evict(t *testing.T) {
+	c := New(100, nil)
+	c.Add("k1", testValue{5})
+	c.Add("k2", testValue{5})
+	c.Add("k3", testValue{5})
+	c.Get("k3")
+
+	// 访问次数最少的k1是keep时淘汰访问次数次少的k2
+	if !c.Evict("k1") {
+		t.Fatal("expected an entry to be evicted")
+	}
+	if _, ok := c.Get("k2"); ok {
+		t.Error("k2 should have been evicted")
+	}
+	if !c.Evict("k1") || c.Len() != 1 {
+		t.Errorf("expected only k1 to remain, got %d items", c.Len())
+	}
+	if c.Evict("k1") {
+		t.Error("expected the kept entry not to be evicted")
+	}
+}
@@ -1,71 +1,164 @@
 package lru
 
-import "container/list"
+import (
+	"container/list"
+	"time"
+	"zencache/internal/eviction"
+)
 
 type Cache struct {
-	maxBytes  int64
-	nBytes    int64
-	ll        *list.List
-	cache     map[string]*list.Element
-	onEvicted func(key string, value Value)
+	maxEntries int // 最大条目数，0表示不限制
+	maxBytes   int64
+	nBytes     int64
+	ll         *list.List
+	cache      map[string]*list.Element
+	onEvicted  func(key string, value Value)
 }
 
 func New(maxBytes int64, onEvicted func(key string, value Value)) *Cache {
+	return NewWithMaxEntries(0, maxBytes, onEvicted)
+}
+
+// NewWithMaxEntries 创建同时限制条目数和字节数的缓存，maxEntries为0表示不限制条目数
+func NewWithMaxEntries(maxEntries int, maxBytes int64, onEvicted func(key string, value Value)) *Cache {
 	return &Cache{
-		maxBytes:  maxBytes,
-		ll:        list.New(),
-		cache:     make(map[string]*list.Element),
-		onEvicted: onEvicted,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ll:         list.New(),
+		cache:      make(map[string]*list.Element),
+		onEvicted:  onEvicted,
 	}
 }
 
-type Value interface {
-	Len() int
-}
+type Value = eviction.Value
+
+var _ eviction.Policy = (*Cache)(nil)
+
 type entry struct {
-	key   string
-	value Value
+	key    string
+	value  Value
+	expire time.Time // 过期时间，零值表示永不过期
 }
 
+func (e *entry) expired(now time.Time) bool {
+	return !e.expire.IsZero() && now.After(e.expire)
+}
+
+// Get 获取缓存值，已过期的条目会被惰性删除
 func (c *Cache) Get(key string) (Value, bool) {
 	element, ok := c.cache[key]
 	if !ok {
 		return nil, ok
 	}
+	kv := element.Value.(*entry)
+	if kv.expired(time.Now()) {
+		c.removeElement(element)
+		return nil, false
+	}
 	c.ll.MoveToFront(element)
-	return element.Value.(*entry).value, ok
+	return kv.value, ok
 }
 
+// Add 添加永不过期的条目
 func (c *Cache) Add(key string, value Value) {
+	c.AddWithExpire(key, value, time.Time{})
+}
+
+// AddWithExpire 添加条目并指定过期时间，零值表示永不过期
+func (c *Cache) AddWithExpire(key string, value Value, expire time.Time) {
 	element, ok := c.cache[key]
 	if ok {
 		c.nBytes += (int64(value.Len()) - int64(element.Value.(*entry).value.Len()))
 		element.Value = &entry{
-			key:   key,
-			value: value,
+			key:    key,
+			value:  value,
+			expire: expire,
 		}
 		c.ll.MoveToFront(element)
-		return
+	} else {
+		element = c.ll.PushFront(&entry{
+			key:    key,
+			value:  value,
+			expire: expire,
+		})
+		c.nBytes += (int64(len(key) + value.Len()))
+		c.cache[key] = element
+	}
+	for c.Len() > 0 && c.overflow() {
+		c.RemoveOldest()
+	}
+}
+
+// overflow 判断是否超出字节数或条目数限制
+func (c *Cache) overflow() bool {
+	return c.nBytes > c.maxBytes || (c.maxEntries > 0 && c.Len() > c.maxEntries)
+}
+
+// Remove 删除指定条目，返回条目是否存在
+func (c *Cache) Remove(key string) bool {
+	element, ok := c.cache[key]
+	if !ok {
+		return false
 	}
-	element = c.ll.PushFront(&entry{
-		key:   key,
-		value: value,
-	})
-	c.nBytes += (int64(len(key) + value.Len()))
-	c.cache[key] = element
-	for c.Len() > 0 && c.nBytes > c.maxBytes {
+	c.removeElement(element)
+	return true
+}
+
+// Resize 调整容量，超出新容量时淘汰最久未使用的条目
+func (c *Cache) Resize(maxEntries int, maxBytes int64) {
+	c.maxEntries = maxEntries
+	c.maxBytes = maxBytes
+	for c.Len() > 0 && c.overflow() {
 		c.RemoveOldest()
 	}
 }
+
+// Evict 淘汰最久未使用且key不为keep的条目
+func (c *Cache) Evict(keep string) bool {
+	for element := c.ll.Back(); element != nil; element = element.Prev() {
+		if element.Value.(*entry).key != keep {
+			c.removeElement(element)
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Cache) RemoveOldest() {
 	element := c.ll.Back()
-	c.nBytes -= int64(len(element.Value.(*entry).key) + element.Value.(*entry).value.Len())
+	if element != nil {
+		c.removeElement(element)
+	}
+}
+
+// RemoveExpired 删除所有已过期的条目，返回删除的数量
+func (c *Cache) RemoveExpired() int {
+	now := time.Now()
+	removed := 0
+	for element := c.ll.Back(); element != nil; {
+		prev := element.Prev()
+		if element.Value.(*entry).expired(now) {
+			c.removeElement(element)
+			removed++
+		}
+		element = prev
+	}
+	return removed
+}
+func (c *Cache) removeElement(element *list.Element) {
+	kv := element.Value.(*entry)
+	c.nBytes -= int64(len(kv.key) + kv.value.Len())
 	c.ll.Remove(element)
-	delete(c.cache, element.Value.(*entry).key)
+	delete(c.cache, kv.key)
 	if c.onEvicted != nil {
-		c.onEvicted(element.Value.(*entry).key, element.Value.(*entry).value)
+		c.onEvicted(kv.key, kv.value)
 	}
 }
 func (c *Cache) Len() int {
 	return c.ll.Len()
 }
+
+// Bytes 返回当前占用的字节数
+func (c *Cache) Bytes() int64 {
+	return c.nBytes
+}
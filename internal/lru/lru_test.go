@@ -2,6 +2,7 @@ package lru
 
 import (
 	"testing"
+	"time"
 )
 
 type testValue struct {
@@ -130,3 +131,123 @@ func TestCache_LRUOrder(t *testing.T) {
 		t.Error("k2 should be evicted")
 	}
 }
+
+func TestCache_Expire(t *testing.T) {
+	c := New(100, nil)
+	c.AddWithExpire("k1", testValue{5}, time.Now().Add(-time.Millisecond))
+	c.AddWithExpire("k2", testValue{5}, time.Now().Add(time.Hour))
+
+	// 已过期的条目在Get时被惰性删除
+	if _, ok := c.Get("k1"); ok {
+		t.Error("k1 should have expired")
+	}
+	if c.Len() != 1 || c.nBytes != 7 {
+		t.Errorf("expected 1 item of 7 bytes, got %d items of %d bytes", c.Len(), c.nBytes)
+	}
+	if _, ok := c.Get("k2"); !ok {
+		t.Error("k2 should not have expired")
+	}
+}
+
+func TestCache_RemoveExpired(t *testing.T) {
+	var evicted []string
+	c := New(100, func(key string, value Value) {
+		evicted = append(evicted, key)
+	})
+	past := time.Now().Add(-time.Millisecond)
+	c.AddWithExpire("k1", testValue{5}, past)
+	c.Add("k2", testValue{5})
+	c.AddWithExpire("k3", testValue{5}, past)
+
+	if n := c.RemoveExpired(); n != 2 {
+		t.Errorf("expected 2 expired items, got %d", n)
+	}
+	if c.Len() != 1 || c.Bytes() != 7 {
+		t.Errorf("expected 1 item of 7 bytes, got %d items of %d bytes", c.Len(), c.Bytes())
+	}
+	if len(evicted) != 2 {
+		t.Errorf("expected eviction callback for expired items, got %v", evicted)
+	}
+}
+
+func TestCache_MaxEntries(t *testing.T) {
+	c := NewWithMaxEntries(2, 100, nil)
+	c.Add("k1", testValue{1})
+	c.Add("k2", testValue{1})
+	c.Add("k3", testValue{1}) // 超出条目数限制，淘汰k1
+
+	if c.Len() != 2 {
+		t.Errorf("expected 2 items, got %d", c.Len())
+	}
+	if _, ok := c.Get("k1"); ok {
+		t.Error("k1 should have been evicted")
+	}
+	if c.nBytes != 6 {
+		t.Errorf("expected size 6, got %d", c.nBytes)
+	}
+}
+
+func TestCache_Remove(t *testing.T) {
+	var evictedKey string
+	c := New(100, func(key string, value Value) {
+		evictedKey = key
+	})
+	c.Add("k1", testValue{10})
+	c.Add("k2", testValue{10})
+
+	if !c.Remove("k1") {
+		t.Fatal("expected k1 to be removed")
+	}
+	if c.Remove("k1") {
+		t.Error("removing a missing key should report false")
+	}
+	if _, ok := c.Get("k1"); ok {
+		t.Error("k1 should be removed")
+	}
+	if c.Len() != 1 || c.nBytes != 12 {
+		t.Errorf("expected 1 item of 12 bytes, got %d items of %d bytes", c.Len(), c.nBytes)
+	}
+	if evictedKey != "k1" {
+		t.Errorf("expected eviction callback for k1, got %q", evictedKey)
+	}
+}
+
+func TestCache_Resize(t *testing.T) {
+	c := New(100, nil)
+	c.Add("k1", testValue{1})
+	c.Add("k2", testValue{1})
+	c.Add("k3", testValue{1})
+
+	c.Resize(0, 6) // 只能保留两个条目，淘汰最旧的k1
+	if _, ok := c.Get("k1"); ok {
+		t.Error("k1 should have been evicted")
+	}
+	if c.Len() != 2 || c.Bytes() != 6 {
+		t.Errorf("expected 2 items of 6 bytes, got %d items of %d bytes", c.Len(), c.Bytes())
+	}
+
+	c.Resize(1, 100)
+	if _, ok := c.Get("k3"); !ok || c.Len() != 1 {
+		t.Errorf("expected only k3 to remain, got %d items", c.Len())
+	}
+}
+
+func TestCache_Evict(t *testing.T) {
+	c := New(100, nil)
+	c.Add("k1", testValue{5})
+	c.Add("k2", testValue{5})
+
+	// 最久未使用的k1是keep时淘汰k2
+	if !c.Evict("k1") {
+		t.Fatal("expected an entry to be evicted")
+	}
+	if _, ok := c.Get("k2"); ok {
+		t.Error("k2 should have been evicted")
+	}
+	if c.Evict("k1") {
+		t.Error("expected the kept entry not to be evicted")
+	}
+	if !c.Evict("") || c.Len() != 0 {
+		t.Errorf("expected k1 to be evicted, got %d items", c.Len())
+	}
+}
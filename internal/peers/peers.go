@@ -1,5 +1,10 @@
 package peers
 
+import (
+	"context"
+	"time"
+)
+
 type PeersPicker interface {
 	PickPeer(key string) (PeerGetter, bool)
 }
@@ -7,3 +12,88 @@ type PeersPicker interface {
 type PeerGetter interface {
 	Get(group string, key string) ([]byte, error)
 }
+
+// ContextPeerGetter 支持通过ctx控制超时和取消的PeerGetter
+type ContextPeerGetter interface {
+	PeerGetter
+	GetContext(ctx context.Context, group string, key string) ([]byte, error)
+}
+
+// GetContext 优先使用ContextPeerGetter，不支持ctx的PeerGetter在ctx结束时提前返回，但请求本身不会被取消
+func GetContext(ctx context.Context, peer PeerGetter, group string, key string) ([]byte, error) {
+	if p, ok := peer.(ContextPeerGetter); ok {
+		return p.GetContext(ctx, group, key)
+	}
+	type result struct {
+		bs  []byte
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		bs, err := peer.Get(group, key)
+		done <- result{bs, err}
+	}()
+	select {
+	case r := <-done:
+		return r.bs, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// PeerSetter 支持写入远程节点上的缓存，ttl<=0时使用远程Group的默认过期时间
+type PeerSetter interface {
+	PeerGetter
+	Set(group string, key string, value []byte, ttl time.Duration) error
+}
+
+// PeerDeleter 支持删除远程节点上的缓存
+type PeerDeleter interface {
+	PeerGetter
+	Delete(group string, key string) error
+}
+
+// Result 批量获取时单个key的结果，未找到的key对应cache.ErrKeyNotFound
+type Result struct {
+	Value []byte
+	Err   error
+}
+
+// PeerBatchGetter 支持一次请求获取远程节点上的多个key，error非空表示整个请求失败
+type PeerBatchGetter interface {
+	PeerGetter
+	GetMany(ctx context.Context, group string, keys []string) (map[string]Result, error)
+}
+
+// PeerBatchSetter 支持一次请求写入远程节点上的多个key，返回写入失败的key及原因
+type PeerBatchSetter interface {
+	PeerSetter
+	SetMany(group string, entries map[string][]byte, ttl time.Duration) (map[string]error, error)
+}
+
+// PeerVersioner 支持带版本号读取远程节点上的缓存，以及按版本号compare-and-swap写入
+type PeerVersioner interface {
+	PeerGetter
+	GetVersion(ctx context.Context, group string, key string) ([]byte, uint64, error)
+	CompareAndSwap(group string, key string, value []byte, version uint64, ttl time.Duration) (uint64, error)
+}
+
+// PeerIncrementer 支持原子地增减远程节点上的计数器，返回新值
+type PeerIncrementer interface {
+	PeerGetter
+	Incr(group string, key string, delta int64, initial int64, ttl time.Duration) (int64, error)
+}
+
+// PeerTagger 支持写入带标签的缓存，以及删除远程节点上带有标签的缓存
+type PeerTagger interface {
+	PeerSetter
+	SetWithTags(group string, key string, value []byte, ttl time.Duration, tags []string) error
+	// InvalidateTag 删除远程节点本地带有tag的缓存，返回删除的条目数
+	InvalidateTag(group string, tag string) (int, error)
+}
+
+// PeerLister 支持列出所有远程节点，用于需要广播给所有节点的操作
+type PeerLister interface {
+	PeersPicker
+	ListPeers() []PeerGetter
+}
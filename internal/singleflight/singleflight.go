@@ -0,0 +1,116 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// errGoexit fn调用了runtime.Goexit，没有返回结果
+var errGoexit = errors.New("singleflight: fn called runtime.Goexit")
+
+// panicError fn中发生的panic及其堆栈，会在每个等待结果的调用方中重新panic
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func (p *panicError) Error() string {
+	return fmt.Sprintf("singleflight: panic: %v\n\n%s", p.value, p.stack)
+}
+
+// call 一次正在进行或已完成的调用
+type call struct {
+	done     chan struct{}
+	val      any
+	err      error
+	panicked bool               // fn发生了panic，err为*panicError
+	waiters  int                // 仍在等待结果的调用方数量
+	cancel   context.CancelFunc // 所有调用方都放弃等待时取消调用
+}
+
+// Group 合并对同一个key的并发调用，同一时刻只有一个调用真正执行
+type Group struct {
+	mu sync.Mutex
+	m  map[string]*call
+}
+
+// Do 执行fn并返回其结果，若同一个key已有调用在执行，则等待该调用完成并共享其结果
+func (g *Group) Do(key string, fn func() (any, error)) (any, error) {
+	return g.DoContext(context.Background(), key, func(context.Context) (any, error) {
+		return fn()
+	})
+}
+
+// DoContext 与Do相同，但每个调用方可以通过ctx单独放弃等待。
+// fn收到的ctx保留第一个调用方ctx中的值，只有所有调用方都放弃等待时才会被取消。
+// fn在单独的goroutine中执行，其中的panic会被恢复，并在每个等待结果的调用方中重新panic
+func (g *Group) DoContext(ctx context.Context, key string, fn func(context.Context) (any, error)) (any, error) {
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+	c, ok := g.m[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		g.m[key] = c
+		go g.run(c, key, callCtx, fn)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		if c.panicked {
+			panic(c.err)
+		}
+		return c.val, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// 没有调用方等待结果，取消调用，之后的调用方重新发起
+			c.cancel()
+			g.forget(key, c)
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// run 执行fn并通知所有调用方，fn发生panic或调用runtime.Goexit时也会通知，避免调用方一直等待
+func (g *Group) run(c *call, key string, ctx context.Context, fn func(context.Context) (any, error)) {
+	normalReturn := false
+	defer func() {
+		if !normalReturn {
+			if r := recover(); r != nil {
+				c.err, c.panicked = &panicError{value: r, stack: debug.Stack()}, true
+			} else {
+				c.err = errGoexit
+			}
+		}
+		c.cancel()
+		g.mu.Lock()
+		g.forget(key, c)
+		waiters := c.waiters
+		g.mu.Unlock()
+		if c.panicked && waiters == 0 {
+			// 所有调用方都已放弃等待，panic无法传递给调用方，只记录下来
+			log.Print(c.err)
+		}
+		close(c.done)
+	}()
+	c.val, c.err = fn(ctx)
+	normalReturn = true
+}
+
+// forget 删除已结束的调用，调用方需持有锁
+func (g *Group) forget(key string, c *call) {
+	if g.m[key] == c {
+		delete(g.m, key)
+	}
+}
@@ -0,0 +1,162 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	var g Group
+	v, err := g.Do("key", func() (any, error) {
+		return "bar", nil
+	})
+	if v.(string) != "bar" || err != nil {
+		t.Errorf("Do = %v; %v", v, err)
+	}
+}
+
+func TestDoErr(t *testing.T) {
+	var g Group
+	someErr := errors.New("some error")
+	v, err := g.Do("key", func() (any, error) {
+		return nil, someErr
+	})
+	if err != someErr {
+		t.Errorf("Do error = %v; want someErr", err)
+	}
+	if v != nil {
+		t.Errorf("unexpected non-nil value %#v", v)
+	}
+}
+
+func TestDoDupSuppress(t *testing.T) {
+	var g Group
+	c := make(chan string)
+	var calls int32
+	fn := func() (any, error) {
+		atomic.AddInt32(&calls, 1)
+		return <-c, nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := g.Do("key", fn)
+			if err != nil {
+				t.Errorf("Do error: %v", err)
+			}
+			if v.(string) != "bar" {
+				t.Errorf("got %q; want %q", v, "bar")
+			}
+		}()
+	}
+	time.Sleep(100 * time.Millisecond) // 等待所有goroutine进入Do
+	c <- "bar"
+	wg.Wait()
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("number of calls = %d; want 1", got)
+	}
+}
+
+func TestDoContextCancelWaiter(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (any, error) {
+		<-release
+		return "bar", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := g.DoContext(ctx, "key", fn)
+		errc <- err
+	}()
+	resc := make(chan any, 1)
+	go func() {
+		v, _ := g.DoContext(context.Background(), "key", fn)
+		resc <- v
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// 一个调用方放弃等待不影响其他调用方
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	close(release)
+	if v := <-resc; v != "bar" {
+		t.Errorf("expected remaining waiter to get result, got %v", v)
+	}
+}
+
+func TestDoContextCancelAll(t *testing.T) {
+	var g Group
+	cancelled := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	_, err := g.DoContext(ctx, "key", func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("expected call to be cancelled once all waiters gave up")
+	}
+}
+
+func TestDoPanicPropagatesToCallers(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	var calls atomic.Int32
+	fn := func() (any, error) {
+		calls.Add(1)
+		<-release
+		panic("boom")
+	}
+
+	const n = 3
+	var recovered atomic.Int32
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					recovered.Add(1)
+				}
+			}()
+			g.Do("key", fn)
+		}()
+	}
+	time.Sleep(100 * time.Millisecond) // 等待所有goroutine进入Do
+	close(release)
+	wg.Wait()
+	if got := recovered.Load(); got != n {
+		t.Errorf("expected the panic to reach every caller, got %d of %d", got, n)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("number of calls = %d; want 1", got)
+	}
+
+	// panic之后同一个key可以重新调用
+	if v, err := g.Do("key", func() (any, error) { return "bar", nil }); err != nil || v != "bar" {
+		t.Errorf("Do after panic = %v; %v", v, err)
+	}
+}
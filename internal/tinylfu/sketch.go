@@ -0,0 +1,81 @@
+package tinylfu
+
+import "hash/maphash"
+
+// sketchDepth count-min sketch的行数
+const sketchDepth = 4
+
+// maxCount 计数器的上限
+const maxCount = 15
+
+// cmSketch 使用count-min sketch估计key的访问频率，
+// 累计增加次数达到采样数量时所有计数减半，使频率随时间衰减
+type cmSketch struct {
+	rows       [sketchDepth][]uint8
+	mask       uint64
+	seed       maphash.Seed
+	additions  int
+	sampleSize int
+}
+
+// newCMSketch 创建宽度为不小于width的2的幂的sketch
+func newCMSketch(width int) *cmSketch {
+	w := 1
+	for w < width {
+		w <<= 1
+	}
+	s := &cmSketch{
+		mask:       uint64(w - 1),
+		seed:       maphash.MakeSeed(),
+		sampleSize: 10 * w,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, w)
+	}
+	return s
+}
+
+// indexes 由一个64位哈希派生出每一行的位置
+func (s *cmSketch) indexes(key string) [sketchDepth]uint64 {
+	h := maphash.String(s.seed, key)
+	h1, h2 := h, h>>32|1
+	var idx [sketchDepth]uint64
+	for i := range idx {
+		idx[i] = (h1 + uint64(i)*h2) & s.mask
+	}
+	return idx
+}
+
+// Increment 增加key的访问计数
+func (s *cmSketch) Increment(key string) {
+	for i, idx := range s.indexes(key) {
+		if s.rows[i][idx] < maxCount {
+			s.rows[i][idx]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+// Estimate 返回key访问次数的估计值
+func (s *cmSketch) Estimate(key string) uint8 {
+	min := uint8(maxCount)
+	for i, idx := range s.indexes(key) {
+		if s.rows[i][idx] < min {
+			min = s.rows[i][idx]
+		}
+	}
+	return min
+}
+
+// reset 所有计数减半
+func (s *cmSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,267 @@
+package tinylfu
+
+import (
+	"container/list"
+	"time"
+	"zencache/internal/eviction"
+)
+
+const (
+	// windowPercent 窗口LRU占总容量的百分比
+	windowPercent = 1
+	// protectedPercent 保护区占主缓存容量的百分比
+	protectedPercent = 80
+	// bytesPerCounter 估算sketch宽度时假定的平均条目大小
+	bytesPerCounter = 64
+	minSketchWidth  = 64
+	maxSketchWidth  = 1 << 20
+)
+
+// 条目所在的区域
+const (
+	window = iota
+	probation
+	protected
+)
+
+// Cache W-TinyLFU淘汰策略：新条目先进入窗口LRU，
+// 被挤出窗口后需要与主缓存（SLRU）的淘汰候选者比较访问频率，频率更高才会被接纳
+type Cache struct {
+	maxEntries   int // 最大条目数，0表示不限制
+	maxBytes     int64
+	nBytes       int64
+	windowMax    int64
+	protectedMax int64
+	segments     [3]segment
+	cache        map[string]*list.Element
+	sketch       *cmSketch
+	onEvicted    func(key string, value eviction.Value)
+}
+
+var _ eviction.Policy = (*Cache)(nil)
+
+type segment struct {
+	ll    *list.List
+	bytes int64
+}
+
+type entry struct {
+	key     string
+	value   eviction.Value
+	expire  time.Time // 过期时间，零值表示永不过期
+	segment int
+}
+
+func (e *entry) size() int64 {
+	return int64(len(e.key) + e.value.Len())
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expire.IsZero() && now.After(e.expire)
+}
+
+func New(maxBytes int64, onEvicted func(key string, value eviction.Value)) *Cache {
+	return NewWithMaxEntries(0, maxBytes, onEvicted)
+}
+
+// NewWithMaxEntries 创建同时限制条目数和字节数的缓存，maxEntries为0表示不限制条目数
+func NewWithMaxEntries(maxEntries int, maxBytes int64, onEvicted func(key string, value eviction.Value)) *Cache {
+	width := int(maxBytes / bytesPerCounter)
+	if maxEntries > 0 {
+		width = min(width, maxEntries)
+	}
+	width = max(minSketchWidth, min(width, maxSketchWidth))
+	windowMax := maxBytes * windowPercent / 100
+	c := &Cache{
+		maxEntries:   maxEntries,
+		maxBytes:     maxBytes,
+		windowMax:    windowMax,
+		protectedMax: (maxBytes - windowMax) * protectedPercent / 100,
+		cache:        make(map[string]*list.Element),
+		sketch:       newCMSketch(width),
+		onEvicted:    onEvicted,
+	}
+	for i := range c.segments {
+		c.segments[i].ll = list.New()
+	}
+	return c
+}
+
+// Get 获取缓存值，已过期的条目会被惰性删除
+func (c *Cache) Get(key string) (eviction.Value, bool) {
+	c.sketch.Increment(key)
+	element, ok := c.cache[key]
+	if !ok {
+		return nil, false
+	}
+	e := element.Value.(*entry)
+	if e.expired(time.Now()) {
+		c.removeElement(element)
+		return nil, false
+	}
+	c.onAccess(element)
+	return e.value, true
+}
+
+// Add 添加永不过期的条目
+func (c *Cache) Add(key string, value eviction.Value) {
+	c.AddWithExpire(key, value, time.Time{})
+}
+
+// AddWithExpire 添加条目并指定过期时间，零值表示永不过期
+func (c *Cache) AddWithExpire(key string, value eviction.Value, expire time.Time) {
+	c.sketch.Increment(key)
+	if element, ok := c.cache[key]; ok {
+		e := element.Value.(*entry)
+		delta := int64(value.Len() - e.value.Len())
+		e.value = value
+		e.expire = expire
+		c.nBytes += delta
+		c.segments[e.segment].bytes += delta
+		c.onAccess(element)
+	} else {
+		e := &entry{key: key, value: value, expire: expire, segment: window}
+		c.cache[key] = c.segments[window].ll.PushFront(e)
+		c.segments[window].bytes += e.size()
+		c.nBytes += e.size()
+	}
+	c.evict()
+}
+
+// onAccess 命中时调整条目位置：试用区的条目晋升到保护区
+func (c *Cache) onAccess(element *list.Element) {
+	e := element.Value.(*entry)
+	switch e.segment {
+	case window, protected:
+		c.segments[e.segment].ll.MoveToFront(element)
+	case probation:
+		c.move(element, protected)
+		// 保护区超出容量时将最旧的条目降级回试用区
+		for c.segments[protected].bytes > c.protectedMax && c.segments[protected].ll.Len() > 1 {
+			c.move(c.segments[protected].ll.Back(), probation)
+		}
+	}
+}
+
+// evict 窗口溢出的条目进入试用区参与接纳竞争，之后仍超出容量时继续淘汰
+func (c *Cache) evict() {
+	for c.segments[window].bytes > c.windowMax && c.segments[window].ll.Len() > 1 {
+		candidate := c.move(c.segments[window].ll.Back(), probation)
+		c.admit(candidate)
+	}
+	for c.Len() > 0 && c.overflow() {
+		c.removeElement(c.victim(nil))
+	}
+}
+
+// Resize 调整容量并按新容量重新划分各区域，sketch的宽度保持不变
+func (c *Cache) Resize(maxEntries int, maxBytes int64) {
+	c.maxEntries = maxEntries
+	c.maxBytes = maxBytes
+	c.windowMax = maxBytes * windowPercent / 100
+	c.protectedMax = (maxBytes - c.windowMax) * protectedPercent / 100
+	for c.segments[protected].bytes > c.protectedMax && c.segments[protected].ll.Len() > 0 {
+		c.move(c.segments[protected].ll.Back(), probation)
+	}
+	c.evict()
+}
+
+// Evict 按淘汰候选者的顺序淘汰一个key不为keep的条目
+func (c *Cache) Evict(keep string) bool {
+	victim := c.victim(c.cache[keep])
+	if victim == nil {
+		return false
+	}
+	c.removeElement(victim)
+	return true
+}
+
+// overflow 判断是否超出字节数或条目数限制
+func (c *Cache) overflow() bool {
+	return c.nBytes > c.maxBytes || (c.maxEntries > 0 && c.Len() > c.maxEntries)
+}
+
+// admit 比较候选者与淘汰候选者的访问频率，淘汰频率较低的一方
+func (c *Cache) admit(candidate *list.Element) {
+	for c.overflow() {
+		victim := c.victim(candidate)
+		if victim == nil || victim == candidate {
+			return
+		}
+		candidateKey := candidate.Value.(*entry).key
+		victimKey := victim.Value.(*entry).key
+		if c.sketch.Estimate(candidateKey) > c.sketch.Estimate(victimKey) {
+			c.removeElement(victim)
+		} else {
+			c.removeElement(candidate)
+			return
+		}
+	}
+}
+
+// victim 依次从试用区、保护区、窗口的尾部选择淘汰候选者，跳过exclude
+func (c *Cache) victim(exclude *list.Element) *list.Element {
+	for _, s := range []int{probation, protected, window} {
+		for element := c.segments[s].ll.Back(); element != nil; element = element.Prev() {
+			if element != exclude {
+				return element
+			}
+		}
+	}
+	return nil
+}
+
+// move 将条目移动到目标区域的头部
+func (c *Cache) move(element *list.Element, to int) *list.Element {
+	e := element.Value.(*entry)
+	c.segments[e.segment].ll.Remove(element)
+	c.segments[e.segment].bytes -= e.size()
+	e.segment = to
+	c.segments[to].bytes += e.size()
+	moved := c.segments[to].ll.PushFront(e)
+	c.cache[e.key] = moved
+	return moved
+}
+
+// Remove 删除指定条目，返回条目是否存在
+func (c *Cache) Remove(key string) bool {
+	element, ok := c.cache[key]
+	if !ok {
+		return false
+	}
+	c.removeElement(element)
+	return true
+}
+
+// RemoveExpired 删除所有已过期的条目，返回删除的数量
+func (c *Cache) RemoveExpired() int {
+	now := time.Now()
+	removed := 0
+	for _, element := range c.cache {
+		if element.Value.(*entry).expired(now) {
+			c.removeElement(element)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *Cache) removeElement(element *list.Element) {
+	e := element.Value.(*entry)
+	c.segments[e.segment].ll.Remove(element)
+	c.segments[e.segment].bytes -= e.size()
+	c.nBytes -= e.size()
+	delete(c.cache, e.key)
+	if c.onEvicted != nil {
+		c.onEvicted(e.key, e.value)
+	}
+}
+
+func (c *Cache) Len() int {
+	return len(c.cache)
+}
+
+// Bytes 返回当前占用的字节数
+func (c *Cache) Bytes() int64 {
+	return c.nBytes
+}
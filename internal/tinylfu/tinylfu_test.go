@@ -0,0 +1,141 @@
+package tinylfu
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"zencache/internal/eviction"
+)
+
+type testValue struct {
+	size int
+}
+
+func (v testValue) Len() int { return v.size }
+
+func TestSketch_Estimate(t *testing.T) {
+	s := newCMSketch(64)
+	for range 5 {
+		s.Increment("hot")
+	}
+	s.Increment("cold")
+
+	if s.Estimate("hot") < 5 {
+		t.Errorf("expected hot estimate >= 5, got %d", s.Estimate("hot"))
+	}
+	if s.Estimate("hot") <= s.Estimate("cold") {
+		t.Error("hot key should have a higher estimate than cold key")
+	}
+}
+
+func TestSketch_Reset(t *testing.T) {
+	s := newCMSketch(64)
+	for range 8 {
+		s.Increment("k")
+	}
+	s.reset()
+	if got := s.Estimate("k"); got != 4 {
+		t.Errorf("expected estimate to be halved to 4, got %d", got)
+	}
+}
+
+func TestCache_AddAndGet(t *testing.T) {
+	c := New(100, nil)
+	c.Add("k1", testValue{10})
+	v, ok := c.Get("k1")
+	if !ok || v != (testValue{10}) {
+		t.Fatalf("expected to get k1, got %v %v", v, ok)
+	}
+	if c.Len() != 1 || c.Bytes() != 12 {
+		t.Errorf("expected 1 item of 12 bytes, got %d items of %d bytes", c.Len(), c.Bytes())
+	}
+}
+
+func TestCache_ScanResistant(t *testing.T) {
+	// 容量约可容纳100个条目
+	c := New(100*7, nil)
+	hot := make([]string, 50)
+	for i := range hot {
+		hot[i] = fmt.Sprintf("h%d", i)
+	}
+	for range 5 {
+		for _, k := range hot {
+			if _, ok := c.Get(k); !ok {
+				c.Add(k, testValue{5})
+			}
+		}
+	}
+
+	// 一次性扫描大量冷数据
+	for i := range 1000 {
+		c.Add(fmt.Sprintf("s%d", i), testValue{5})
+	}
+
+	survived := 0
+	for _, k := range hot {
+		if _, ok := c.Get(k); ok {
+			survived++
+		}
+	}
+	if survived < len(hot)*9/10 {
+		t.Errorf("expected hot keys to survive the scan, only %d/%d survived", survived, len(hot))
+	}
+	if c.Bytes() > 100*7 {
+		t.Errorf("cache exceeded max bytes: %d", c.Bytes())
+	}
+}
+
+func TestCache_Expire(t *testing.T) {
+	var evicted []string
+	c := New(100, func(key string, value eviction.Value) {
+		evicted = append(evicted, key)
+	})
+	c.AddWithExpire("k1", testValue{5}, time.Now().Add(-time.Millisecond))
+	c.AddWithExpire("k2", testValue{5}, time.Now().Add(-time.Millisecond))
+	c.Add("k3", testValue{5})
+
+	if _, ok := c.Get("k1"); ok {
+		t.Error("k1 should have expired")
+	}
+	if n := c.RemoveExpired(); n != 1 {
+		t.Errorf("expected 1 expired item, got %d", n)
+	}
+	if c.Len() != 1 || c.Bytes() != 7 || len(evicted) != 2 {
+		t.Errorf("unexpected state: %d items, %d bytes, evicted %v", c.Len(), c.Bytes(), evicted)
+	}
+}
+
+func TestCache_Resize(t *testing.T) {
+	c := New(100*7, nil)
+	for i := range 100 {
+		c.Add(fmt.Sprintf("k%02d", i), testValue{4})
+	}
+	c.Resize(0, 10*7)
+	if c.Bytes() > 10*7 || c.Len() == 0 {
+		t.Errorf("expected shrinking to fit 70 bytes, got %d items of %d bytes", c.Len(), c.Bytes())
+	}
+	var segmentBytes int64
+	for _, s := range c.segments {
+		segmentBytes += s.bytes
+	}
+	if segmentBytes != c.Bytes() || c.segments[protected].bytes > c.protectedMax {
+		t.Errorf("expected segments to be rebalanced, got %d/%d bytes protected %d/%d",
+			segmentBytes, c.Bytes(), c.segments[protected].bytes, c.protectedMax)
+	}
+}
+
+func TestCache_Evict(t *testing.T) {
+	c := New(1000, nil)
+	c.Add("k1", testValue{5})
+	c.Add("k2", testValue{5})
+
+	if !c.Evict("k2") {
+		t.Fatal("expected an entry to be evicted")
+	}
+	if _, ok := c.Get("k2"); !ok {
+		t.Error("expected the kept entry to remain")
+	}
+	if c.Evict("k2") || c.Len() != 1 {
+		t.Errorf("expected only the kept entry to remain, got %d items", c.Len())
+	}
+}
@@ -4,4 +4,33 @@ const (
 	STORE_KEY  = "/v1/store_key"
 	GET_KEY    = "/v1/get_key"
 	DELETE_KEY = "/v1/delete_key"
+	GET_MANY   = "/v1/get_many"
+	STORE_MANY = "/v1/store_many"
+
+	COMPARE_AND_SWAP = "/v1/compare_and_swap"
+	INCR             = "/v1/incr"
+	DECR             = "/v1/decr"
+	INVALIDATE_TAG   = "/v1/invalidate_tag"
+)
+
+// 管理Group的接口，只作用于接收请求的节点
+const (
+	ADD_GROUP    = "/v1/add_group"
+	REMOVE_GROUP = "/v1/remove_group"
+	LIST_GROUPS  = "/v1/list_groups"
+	FLUSH_GROUP  = "/v1/flush_group"
+	RESIZE_GROUP = "/v1/resize_group"
+)
+
+// 节点间通信的内部接口，请求体为protobuf编码
+const (
+	PEER_GET_KEY    = "/v1/peer/get_key"
+	PEER_STORE_KEY  = "/v1/peer/store_key"
+	PEER_DELETE_KEY = "/v1/peer/delete_key"
+	PEER_GET_MANY   = "/v1/peer/get_many"
+	PEER_STORE_MANY = "/v1/peer/store_many"
+
+	PEER_COMPARE_AND_SWAP = "/v1/peer/compare_and_swap"
+	PEER_INCR             = "/v1/peer/incr"
+	PEER_INVALIDATE_TAG   = "/v1/peer/invalidate_tag"
 )
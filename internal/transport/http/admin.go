@@ -0,0 +1,96 @@
+package http
+
+import (
+	"net/http"
+	v1 "zencache/internal/transport/api/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindGroupRequest 解析管理Group的请求，group为空时返回400
+func bindGroupRequest(c *gin.Context) (*v1.GroupRequest, bool) {
+	var req v1.GroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, v1.Response{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	if req.Group == "" {
+		c.JSON(http.StatusBadRequest, v1.Response{
+			Code:    http.StatusBadRequest,
+			Message: "group is required",
+		})
+		return nil, false
+	}
+	return &req, true
+}
+
+// adminResponse 根据错误写入响应
+func adminResponse(c *gin.Context, err error) {
+	if err != nil {
+		jsonError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, v1.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+	})
+}
+
+func (s *Server) handleAddGroup(c *gin.Context) {
+	req, ok := bindGroupRequest(c)
+	if !ok {
+		return
+	}
+	maxBytes := req.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = s.conf.Cache.MaxBytes
+	}
+	if req.Replace {
+		_, err := s.cacheEngine.ReplaceGroup(req.Group, nil, maxBytes)
+		adminResponse(c, err)
+		return
+	}
+	adminResponse(c, s.cacheEngine.AddGroup(req.Group, nil, maxBytes))
+}
+
+func (s *Server) handleRemoveGroup(c *gin.Context) {
+	req, ok := bindGroupRequest(c)
+	if !ok {
+		return
+	}
+	adminResponse(c, s.cacheEngine.RemoveGroup(req.Group))
+}
+
+func (s *Server) handleListGroups(c *gin.Context) {
+	c.JSON(http.StatusOK, v1.ListGroupsResponse{
+		Code:    http.StatusOK,
+		Message: "success",
+		Groups:  s.cacheEngine.ListGroups(),
+	})
+}
+
+func (s *Server) handleFlushGroup(c *gin.Context) {
+	req, ok := bindGroupRequest(c)
+	if !ok {
+		return
+	}
+	adminResponse(c, s.cacheEngine.Flush(req.Group))
+}
+
+func (s *Server) handleResizeGroup(c *gin.Context) {
+	req, ok := bindGroupRequest(c)
+	if !ok {
+		return
+	}
+	if req.MaxBytes <= 0 {
+		c.JSON(http.StatusBadRequest, v1.Response{
+			Code:    http.StatusBadRequest,
+			Message: "max_bytes must be positive",
+		})
+		return
+	}
+	adminResponse(c, s.cacheEngine.Resize(req.Group, req.MaxBytes))
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+	"zencache/internal/cache"
+	"zencache/internal/peers"
+	v1 "zencache/internal/transport/api/v1"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	_ peers.PeerBatchGetter = (*httpGetter)(nil)
+	_ peers.PeerBatchSetter = (*httpGetter)(nil)
+)
+
+// GetMany 通过一次请求从远程节点获取多个key
+func (h *httpGetter) GetMany(ctx context.Context, group string, keys []string) (map[string]peers.Result, error) {
+	resp, err := h.postBatch(ctx, v1.PEER_GET_MANY, &v1.GetManyRequest{Group: group, Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]peers.Result, len(resp.Results))
+	for _, r := range resp.Results {
+		results[r.Key] = peers.Result{Value: r.Value, Err: resultError(r)}
+	}
+	return results, nil
+}
+
+// SetMany 通过一次请求写入远程节点上的多个key
+func (h *httpGetter) SetMany(group string, entries map[string][]byte, ttl time.Duration) (map[string]error, error) {
+	req := &v1.StoreManyRequest{
+		Group:   group,
+		Entries: make([]*v1.KeyValue, 0, len(entries)),
+		TtlMs:   ttl.Milliseconds(),
+	}
+	for key, value := range entries {
+		req.Entries = append(req.Entries, &v1.KeyValue{Key: key, Value: value})
+	}
+	resp, err := h.postBatch(context.Background(), v1.PEER_STORE_MANY, req)
+	if err != nil {
+		return nil, err
+	}
+	failed := make(map[string]error)
+	for _, r := range resp.Results {
+		if err := resultError(r); err != nil {
+			failed[r.Key] = err
+		}
+	}
+	return failed, nil
+}
+
+// postBatch 发送批量请求并解析protobuf编码的响应
+func (h *httpGetter) postBatch(ctx context.Context, path string, req proto.Message) (*v1.BatchResponse, error) {
+	response, err := h.post(ctx, path, req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	var resp v1.BatchResponse
+	if err := proto.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// keyResult 将单个key的结果转换为响应
+func keyResult(key string, value []byte, err error) *v1.KeyResult {
+	if err != nil {
+		return &v1.KeyResult{Key: key, Code: int32(errorStatus(err)), Message: err.Error()}
+	}
+	return &v1.KeyResult{Key: key, Code: http.StatusOK, Message: "success", Value: value}
+}
+
+// resultError 将单个key的响应转换为错误，与errorStatus相对应
+func resultError(r *v1.KeyResult) error {
+	switch r.Code {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return cache.ErrKeyNotFound
+	case http.StatusBadRequest:
+		return cache.ErrKeyIsNil
+	}
+	return errors.New(r.Message)
+}
+
+// getManyResponse 按请求中key的顺序生成响应
+func getManyResponse(keys []string, results map[string]cache.Result) *v1.BatchResponse {
+	resp := &v1.BatchResponse{
+		Code:    http.StatusOK,
+		Message: "success",
+		Results: make([]*v1.KeyResult, 0, len(keys)),
+	}
+	for _, key := range keys {
+		r := results[key]
+		var value []byte
+		if r.Err == nil {
+			value = r.Value.ByteSlices()
+		}
+		resp.Results = append(resp.Results, keyResult(key, value, r.Err))
+	}
+	return resp
+}
+
+// storeManyResponse 按请求中key的顺序生成响应
+func storeManyResponse(entries []*v1.KeyValue, failed map[string]error) *v1.BatchResponse {
+	resp := &v1.BatchResponse{
+		Code:    http.StatusOK,
+		Message: "success",
+		Results: make([]*v1.KeyResult, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		resp.Results = append(resp.Results, keyResult(entry.Key, nil, failed[entry.Key]))
+	}
+	return resp
+}
+
+// byteViews 将请求中的键值对转换为SetMany的参数，重复的key以最后一个为准
+func byteViews(entries []*v1.KeyValue) map[string]cache.ByteView {
+	values := make(map[string]cache.ByteView, len(entries))
+	for _, entry := range entries {
+		values[entry.Key] = cache.NewByteView(entry.Value)
+	}
+	return values
+}
+
+func (s *Server) handleGetMany(c *gin.Context) {
+	var req v1.GetManyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, v1.Response{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// 本节点没有该Group时不创建，按所属节点转发，属于本节点的key返回404
+	var results map[string]cache.Result
+	if group := s.cacheEngine.GetGroup(req.Group); group != nil {
+		results = group.GetMany(c.Request.Context(), req.Keys)
+	} else {
+		results = cache.GetManyOnPeers(c.Request.Context(), s, req.Group, req.Keys)
+	}
+	c.JSON(http.StatusOK, getManyResponse(req.Keys, results))
+}
+
+func (s *Server) handleStoreMany(c *gin.Context) {
+	var req v1.StoreManyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, v1.Response{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	group, err := s.group(req.Group)
+	if err != nil {
+		jsonError(c, err)
+		return
+	}
+	ttl := time.Duration(req.TtlMs) * time.Millisecond
+	failed := group.SetMany(byteViews(req.Entries), ttl)
+	c.JSON(http.StatusOK, storeManyResponse(req.Entries, failed))
+}
+
+func (s *Server) handlePeerGetMany(c *gin.Context) {
+	var req v1.GetManyRequest
+	if !bindProto(c, &req) {
+		return
+	}
+	s.checkForwarded(c, req.Group, req.Keys...)
+	group, ok := s.peerGroup(c, req.Group)
+	if !ok {
+		return
+	}
+	results := group.GetManyLocal(c.Request.Context(), req.Keys)
+	c.ProtoBuf(http.StatusOK, getManyResponse(req.Keys, results))
+}
+
+func (s *Server) handlePeerStoreMany(c *gin.Context) {
+	var req v1.StoreManyRequest
+	if !bindProto(c, &req) {
+		return
+	}
+	keys := make([]string, len(req.Entries))
+	for i, entry := range req.Entries {
+		keys[i] = entry.Key
+	}
+	s.checkForwarded(c, req.Group, keys...)
+	group, err := s.group(req.Group)
+	if err != nil {
+		peerError(c, err)
+		return
+	}
+	ttl := time.Duration(req.TtlMs) * time.Millisecond
+	failed := group.SetManyLocal(byteViews(req.Entries), ttl)
+	c.ProtoBuf(http.StatusOK, storeManyResponse(req.Entries, failed))
+}
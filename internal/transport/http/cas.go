@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"zencache/internal/cache"
+	"zencache/internal/peers"
+	v1 "zencache/internal/transport/api/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ peers.PeerVersioner = (*httpGetter)(nil)
+
+// GetVersion 从远程获取value及其版本号，版本号通过响应头返回
+func (h *httpGetter) GetVersion(ctx context.Context, group string, key string) ([]byte, uint64, error) {
+	response, err := h.post(ctx, v1.PEER_GET_KEY, &v1.GetRequest{Group: group, Key: key, WithVersion: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer response.Body.Close()
+	version, err := responseVersion(response)
+	if err != nil {
+		return nil, 0, err
+	}
+	value, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, version, nil
+}
+
+// CompareAndSwap 在远程节点上按版本号写入，返回新的版本号
+func (h *httpGetter) CompareAndSwap(group string, key string, value []byte, version uint64, ttl time.Duration) (uint64, error) {
+	response, err := h.post(context.Background(), v1.PEER_COMPARE_AND_SWAP, &v1.CompareAndSwapRequest{
+		Group:   group,
+		Key:     key,
+		Value:   value,
+		Version: version,
+		TtlMs:   ttl.Milliseconds(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	return responseVersion(response)
+}
+
+// responseVersion 解析响应头中的版本号
+func responseVersion(response *http.Response) (uint64, error) {
+	return strconv.ParseUint(response.Header.Get(versionHeader), 10, 64)
+}
+
+func (s *Server) handleCompareAndSwap(c *gin.Context) {
+	var req v1.CompareAndSwapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, v1.Response{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	group, err := s.group(req.Group)
+	if err != nil {
+		jsonError(c, err)
+		return
+	}
+
+	ttl := time.Duration(req.TtlMs) * time.Millisecond
+	version, err := group.CompareAndSwap(req.Key, cache.NewByteView(req.Value), req.Version, ttl)
+	if err != nil {
+		jsonError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, v1.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Version: version,
+	})
+}
+
+func (s *Server) handlePeerCompareAndSwap(c *gin.Context) {
+	var req v1.CompareAndSwapRequest
+	if !bindProto(c, &req) {
+		return
+	}
+	s.checkForwarded(c, req.Group, req.Key)
+	group, err := s.group(req.Group)
+	if err != nil {
+		peerError(c, err)
+		return
+	}
+	ttl := time.Duration(req.TtlMs) * time.Millisecond
+	version, err := group.CompareAndSwapLocal(req.Key, cache.NewByteView(req.Value), req.Version, ttl)
+	if err != nil {
+		peerError(c, err)
+		return
+	}
+	c.Header(versionHeader, strconv.FormatUint(version, 10))
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"zencache/internal/peers"
+	v1 "zencache/internal/transport/api/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ peers.PeerIncrementer = (*httpGetter)(nil)
+
+// Incr 增减远程节点上的计数器，响应体为十进制的新值
+func (h *httpGetter) Incr(group string, key string, delta int64, initial int64, ttl time.Duration) (int64, error) {
+	response, err := h.post(context.Background(), v1.PEER_INCR, &v1.IncrRequest{
+		Group:   group,
+		Key:     key,
+		Delta:   delta,
+		Initial: initial,
+		TtlMs:   ttl.Milliseconds(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(body), 10, 64)
+}
+
+func (s *Server) handleIncr(c *gin.Context) {
+	s.incr(c, 1)
+}
+
+func (s *Server) handleDecr(c *gin.Context) {
+	s.incr(c, -1)
+}
+
+// incr 处理增减计数器的请求，sign为-1时调用Decr减少delta
+func (s *Server) incr(c *gin.Context, sign int64) {
+	var req v1.IncrRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, v1.Response{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	group, err := s.group(req.Group)
+	if err != nil {
+		jsonError(c, err)
+		return
+	}
+
+	ttl := time.Duration(req.TtlMs) * time.Millisecond
+	var n int64
+	if sign < 0 {
+		n, err = group.Decr(req.Key, req.Delta, req.Initial, ttl)
+	} else {
+		n, err = group.Incr(req.Key, req.Delta, req.Initial, ttl)
+	}
+	if err != nil {
+		jsonError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, v1.IncrResponse{
+		Code:    http.StatusOK,
+		Message: "success",
+		Value:   n,
+	})
+}
+
+func (s *Server) handlePeerIncr(c *gin.Context) {
+	var req v1.IncrRequest
+	if !bindProto(c, &req) {
+		return
+	}
+	s.checkForwarded(c, req.Group, req.Key)
+	group, err := s.group(req.Group)
+	if err != nil {
+		peerError(c, err)
+		return
+	}
+	ttl := time.Duration(req.TtlMs) * time.Millisecond
+	n, err := group.IncrLocal(req.Key, req.Delta, req.Initial, ttl)
+	if err != nil {
+		peerError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, valueContentType, strconv.AppendInt(nil, n, 10))
+}
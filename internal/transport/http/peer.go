@@ -0,0 +1,284 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"zencache/internal/cache"
+	"zencache/internal/peers"
+	v1 "zencache/internal/transport/api/v1"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// protobufContentType 节点间请求体使用protobuf编码
+	protobufContentType = "application/x-protobuf"
+	// valueContentType 节点间响应体为原始的value字节
+	valueContentType = "application/octet-stream"
+	// maxPeerErrorBody 读取错误响应时最多读取的字节数
+	maxPeerErrorBody = 1 << 10
+	// forwardedByHeader 标记请求由哪个节点转发而来
+	forwardedByHeader = "X-ZenCache-Forwarded-By"
+	// versionHeader 带版本号的响应中条目的版本号
+	versionHeader = "X-ZenCache-Version"
+)
+
+// newPeerClient 创建节点间通信使用的客户端，复用长连接
+func newPeerClient(timeout time.Duration, maxIdleConnsPerHost int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+// httpGetter 通过节点间的内部接口访问远程节点，请求体为protobuf，get响应体为原始的value字节
+type httpGetter struct {
+	baseURL string
+	origin  string // 本节点地址，随请求发送给远程节点
+	client  *http.Client
+}
+
+var (
+	_ peers.ContextPeerGetter = (*httpGetter)(nil)
+	_ peers.PeerSetter        = (*httpGetter)(nil)
+	_ peers.PeerDeleter       = (*httpGetter)(nil)
+)
+
+// 从远程获取
+func (h *httpGetter) Get(group string, key string) ([]byte, error) {
+	return h.GetContext(context.Background(), group, key)
+}
+
+// GetContext 从远程获取，ctx结束时取消请求
+func (h *httpGetter) GetContext(ctx context.Context, group string, key string) ([]byte, error) {
+	response, err := h.post(ctx, v1.PEER_GET_KEY, &v1.GetRequest{Group: group, Key: key})
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	return io.ReadAll(response.Body)
+}
+
+// 写入远程节点上的缓存
+func (h *httpGetter) Set(group string, key string, value []byte, ttl time.Duration) error {
+	return h.SetWithTags(group, key, value, ttl, nil)
+}
+
+// SetWithTags 写入远程节点上带标签的缓存
+func (h *httpGetter) SetWithTags(group string, key string, value []byte, ttl time.Duration, tags []string) error {
+	response, err := h.post(context.Background(), v1.PEER_STORE_KEY, &v1.StoreRequest{
+		Group: group,
+		Key:   key,
+		Value: value,
+		TtlMs: ttl.Milliseconds(),
+		Tags:  tags,
+	})
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+// 删除远程节点上的缓存
+func (h *httpGetter) Delete(group string, key string) error {
+	response, err := h.post(context.Background(), v1.PEER_DELETE_KEY, &v1.DeleteRequest{Group: group, Key: key})
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+// post 发送protobuf编码的请求，非200的响应会转换为错误，404对应cache.ErrKeyNotFound，
+// 412对应cache.ErrVersionMismatch，422对应cache.ErrInvalidCounter
+func (h *httpGetter) post(ctx context.Context, path string, req proto.Message) (*http.Response, error) {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, h.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", protobufContentType)
+	request.Header.Set(forwardedByHeader, h.origin)
+	response, err := h.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusOK {
+		return response, nil
+	}
+	defer response.Body.Close()
+	// 读完响应体以便连接被复用
+	msg, _ := io.ReadAll(io.LimitReader(response.Body, maxPeerErrorBody))
+	io.Copy(io.Discard, response.Body)
+	switch response.StatusCode {
+	case http.StatusNotFound:
+		return nil, cache.ErrKeyNotFound
+	case http.StatusPreconditionFailed:
+		return nil, cache.ErrVersionMismatch
+	case http.StatusUnprocessableEntity:
+		return nil, cache.ErrInvalidCounter
+	}
+	return nil, fmt.Errorf("peer %s: response status %s: %s", h.baseURL, response.Status, bytes.TrimSpace(msg))
+}
+
+// bindProto 解析protobuf编码的请求体
+func bindProto(c *gin.Context, req proto.Message) bool {
+	body, err := io.ReadAll(c.Request.Body)
+	if err == nil {
+		err = proto.Unmarshal(body, req)
+	}
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// checkForwarded 检查转发来的请求中的key是否属于本节点，不属于时说明节点间的哈希环不一致，记录下来以便排查。
+// 批量请求中有多个key不属于本节点时也只计数和记录一次
+func (s *Server) checkForwarded(c *gin.Context, group string, keys ...string) {
+	drifted := 0
+	var key string
+	var peer peers.PeerGetter
+	for _, k := range keys {
+		if p, ok := s.PickPeer(k); ok {
+			if drifted == 0 {
+				key, peer = k, p
+			}
+			drifted++
+		}
+	}
+	if drifted == 0 {
+		return
+	}
+	s.ringDrift.Add(1)
+	owner := "unknown"
+	if getter, ok := peer.(*httpGetter); ok {
+		owner = getter.baseURL
+	}
+	log.Printf("ring drift: %d of %d keys in %s forwarded by %s are not owned by %s, e.g. %s is owned by %s",
+		drifted, len(keys), group, c.GetHeader(forwardedByHeader), s.self, key, owner)
+}
+
+// RingDrift 返回收到的不属于本节点的转发请求数
+func (s *Server) RingDrift() int64 {
+	return s.ringDrift.Load()
+}
+
+// peerError 将错误写入响应，ErrKeyNotFound对应404
+func peerError(c *gin.Context, err error) {
+	c.String(errorStatus(err), err.Error())
+}
+
+// errorStatus 返回错误对应的HTTP状态码
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, cache.ErrKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, cache.ErrKeyIsNil), errors.Is(err, cache.ErrTagIsNil):
+		return http.StatusBadRequest
+	case errors.Is(err, cache.ErrGroupNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, cache.ErrGroupExists):
+		return http.StatusConflict
+	case errors.Is(err, cache.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, cache.ErrInvalidCounter):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, cache.ErrMemoryBudgetExceeded), errors.Is(err, cache.ErrTooManyGroups):
+		return http.StatusInsufficientStorage
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// peerGroup 获取已存在的Group，不存在时写入404响应，转发来的读取请求不会创建Group
+func (s *Server) peerGroup(c *gin.Context, name string) (*cache.Group, bool) {
+	group := s.cacheEngine.GetGroup(name)
+	if group == nil {
+		peerError(c, cache.ErrGroupNotFound)
+		return nil, false
+	}
+	return group, true
+}
+
+func (s *Server) handlePeerGetKey(c *gin.Context) {
+	var req v1.GetRequest
+	if !bindProto(c, &req) {
+		return
+	}
+	// 转发来的请求只在本地处理，避免节点间哈希环不一致时循环转发
+	s.checkForwarded(c, req.Group, req.Key)
+	group, ok := s.peerGroup(c, req.Group)
+	if !ok {
+		return
+	}
+	var value cache.ByteView
+	var version uint64
+	var err error
+	if req.WithVersion {
+		value, version, err = group.GetVersionLocal(c.Request.Context(), req.Key)
+	} else {
+		value, err = group.GetLocalContext(c.Request.Context(), req.Key)
+	}
+	if err != nil {
+		peerError(c, err)
+		return
+	}
+	if req.WithVersion {
+		c.Header(versionHeader, strconv.FormatUint(version, 10))
+	}
+	// 直接从ByteView写入响应，不复制数据
+	c.DataFromReader(http.StatusOK, int64(value.Len()), valueContentType, value.Reader(), nil)
+}
+
+func (s *Server) handlePeerStoreKey(c *gin.Context) {
+	var req v1.StoreRequest
+	if !bindProto(c, &req) {
+		return
+	}
+	s.checkForwarded(c, req.Group, req.Key)
+	ttl := time.Duration(req.TtlMs) * time.Millisecond
+	group, err := s.group(req.Group)
+	if err != nil {
+		peerError(c, err)
+		return
+	}
+	if err := group.AddLocal(req.Key, cache.NewByteView(req.Value), ttl, req.Tags...); err != nil {
+		peerError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) handlePeerDeleteKey(c *gin.Context) {
+	var req v1.DeleteRequest
+	if !bindProto(c, &req) {
+		return
+	}
+	s.checkForwarded(c, req.Group, req.Key)
+	group := s.cacheEngine.GetGroup(req.Group)
+	if group == nil {
+		// 本节点没有该Group，也就没有需要删除的数据
+		c.Status(http.StatusOK)
+		return
+	}
+	if err := group.DeleteLocal(req.Key); err != nil {
+		peerError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
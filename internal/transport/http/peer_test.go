@@ -0,0 +1,347 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"zencache/internal/cache"
+	"zencache/internal/config"
+	"zencache/internal/consistenthash"
+	v1 "zencache/internal/transport/api/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestCluster 启动n个互为对等节点的Server
+func newTestCluster(t *testing.T, n int) []*Server {
+	t.Helper()
+	gin.SetMode("release")
+	listeners := make([]*httptest.Server, n)
+	addrs := make([]string, n)
+	servers := make([]*Server, n)
+	for i := range listeners {
+		listeners[i] = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			servers[i].ginEngine.ServeHTTP(w, r)
+		}))
+		addrs[i] = listeners[i].Listener.Addr().String()
+	}
+	for i := range servers {
+		conf := config.DefaultConfig
+		conf.Cluster.Self = addrs[i]
+		conf.Cluster.Peers = addrs
+		servers[i] = NewWithConfig(&conf)
+	}
+	for _, l := range listeners {
+		l.Start()
+		t.Cleanup(l.Close)
+	}
+	return servers
+}
+
+// testGroup 获取Server上的Group
+func testGroup(t *testing.T, s *Server, name string) *cache.Group {
+	t.Helper()
+	g, err := s.group(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestHTTPGetter(t *testing.T) {
+	servers := newTestCluster(t, 1)
+	s := servers[0]
+	getter := &httpGetter{
+		baseURL: "http://" + s.self,
+		client:  newPeerClient(time.Second, 1),
+	}
+
+	key := `a "quoted", key/with{json}`
+	value := []byte{0, 1, 2, '"', '}', 255}
+	if err := getter.Set("g", key, value, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, err := getter.Get("g", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(value) {
+		t.Errorf("expected exact value bytes %v, got %v", value, got)
+	}
+
+	if err := getter.Delete("g", key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getter.Get("g", key); err != cache.ErrKeyNotFound {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestHTTPGetter_UnknownGroup(t *testing.T) {
+	servers := newTestCluster(t, 1)
+	s := servers[0]
+	getter := &httpGetter{
+		baseURL: "http://" + s.self,
+		client:  newPeerClient(time.Second, 1),
+	}
+
+	if _, err := getter.Get("nope", "k"); err != cache.ErrKeyNotFound {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if err := getter.Delete("nope", "k"); err != nil {
+		t.Errorf("expected deleting from an unknown group to succeed, got %v", err)
+	}
+	if n, err := getter.InvalidateTag("nope", "t"); err != nil || n != 0 {
+		t.Errorf("expected nothing to invalidate, got %d %v", n, err)
+	}
+	if groups := s.cacheEngine.ListGroups(); len(groups) != 0 {
+		t.Errorf("expected forwarded reads not to create groups, got %v", groups)
+	}
+}
+
+func TestHTTPGetter_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	getter := &httpGetter{baseURL: ts.URL, client: newPeerClient(time.Second, 1)}
+
+	_, err := getter.Get("g", "k")
+	if err == nil || err == cache.ErrKeyNotFound || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected a failure distinct from not found, got %v", err)
+	}
+}
+
+func TestCluster_ReadAfterWrite(t *testing.T) {
+	servers := newTestCluster(t, 3)
+	for i := range 20 {
+		key := strings.Repeat("k", i+1)
+		if err := testGroup(t, servers[i%3], "g").Add(key, cache.NewByteView([]byte(key))); err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range servers {
+			v, err := testGroup(t, s, "g").Get(key)
+			if err != nil || v.String() != key {
+				t.Errorf("node %s: expected %q, got %q %v", s.self, key, v.String(), err)
+			}
+		}
+	}
+}
+
+func TestCluster_ReadDeleteThroughNodeWithoutGroup(t *testing.T) {
+	servers := newTestCluster(t, 3)
+	node := servers[2]
+	// 选择不属于node的key，写入后node上仍然没有该Group
+	var keys []string
+	var local string
+	for i := 0; len(keys) < 3 || local == ""; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if _, ok := node.PickPeer(key); ok {
+			keys = append(keys, key)
+		} else if local == "" {
+			local = key
+		}
+	}
+	for _, key := range keys {
+		if code, body := postJSON(servers[0], http.MethodPost, v1.STORE_KEY, &v1.StoreRequest{Group: "g", Key: key, Value: []byte(key)}); code != http.StatusOK {
+			t.Fatalf("expected store to succeed, got %d %s", code, body)
+		}
+	}
+	if g := node.cacheEngine.GetGroup("g"); g != nil {
+		t.Fatal("expected the node to have no group before reading")
+	}
+
+	code, body := postJSON(node, http.MethodPost, v1.GET_KEY, &v1.GetRequest{Group: "g", Key: keys[0]})
+	var resp v1.Response
+	if err := json.Unmarshal(body, &resp); err != nil || code != http.StatusOK || string(resp.Data) != keys[0] {
+		t.Errorf("expected the read to reach the owner, got %d %s %v", code, body, err)
+	}
+	code, body = postJSON(node, http.MethodPost, v1.GET_MANY, &v1.GetManyRequest{Group: "g", Keys: keys})
+	var batch v1.BatchResponse
+	if err := json.Unmarshal(body, &batch); err != nil || code != http.StatusOK {
+		t.Fatalf("expected a batch response, got %d %s %v", code, body, err)
+	}
+	for _, r := range batch.Results {
+		if r.Code != http.StatusOK || string(r.Value) != r.Key {
+			t.Errorf("expected %s to be read from its owner, got %d %s", r.Key, r.Code, r.Message)
+		}
+	}
+	// key属于本节点时数据不可能存在
+	if code, _ := postJSON(node, http.MethodPost, v1.GET_KEY, &v1.GetRequest{Group: "g", Key: local}); code != http.StatusNotFound {
+		t.Errorf("expected a locally owned key to return 404, got %d", code)
+	}
+
+	if code, body := postJSON(node, http.MethodPost, v1.DELETE_KEY, &v1.DeleteRequest{Group: "g", Key: keys[0]}); code != http.StatusOK {
+		t.Errorf("expected the delete to reach the owner, got %d %s", code, body)
+	}
+	if code, _ := postJSON(servers[0], http.MethodPost, v1.GET_KEY, &v1.GetRequest{Group: "g", Key: keys[0]}); code != http.StatusNotFound {
+		t.Errorf("expected the deleted key to be gone cluster-wide, got %d", code)
+	}
+	if g := node.cacheEngine.GetGroup("g"); g != nil {
+		t.Error("expected reads and deletes not to create the group")
+	}
+}
+
+func TestCluster_NoForwardingLoop(t *testing.T) {
+	servers := newTestCluster(t, 2)
+	// 两个节点都认为所有key属于对方
+	for i, s := range servers {
+		other := servers[1-i].self
+		s.mutex.Lock()
+		s.peers = consistenthash.New(s.conf, crc32.ChecksumIEEE)
+		s.peers.Add(other)
+		s.mutex.Unlock()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		g, err := servers[0].group("g")
+		if err == nil {
+			_, err = g.Get("k")
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != cache.ErrKeyNotFound {
+			t.Errorf("expected ErrKeyNotFound served by the peer, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("request was forwarded in a loop")
+	}
+	if servers[1].RingDrift() != 1 {
+		t.Errorf("expected ring drift to be counted on the receiving node, got %d", servers[1].RingDrift())
+	}
+
+	// 批量请求中的多个key只计数一次
+	testGroup(t, servers[0], "g").GetMany(context.Background(), []string{"a", "b", "c"})
+	if servers[1].RingDrift() != 2 {
+		t.Errorf("expected a drifted batch to be counted once, got %d", servers[1].RingDrift())
+	}
+}
+
+func TestCluster_GetManySetMany(t *testing.T) {
+	servers := newTestCluster(t, 3)
+	entries := make(map[string]cache.ByteView)
+	var keys []string
+	for i := range 30 {
+		key := strings.Repeat("k", i+1)
+		keys = append(keys, key)
+		entries[key] = cache.NewByteView([]byte(key))
+	}
+	if failed := testGroup(t, servers[0], "g").SetMany(entries, 0); len(failed) != 0 {
+		t.Fatalf("expected all keys to be stored, got %v", failed)
+	}
+
+	keys = append(keys, "missing")
+	for _, s := range servers {
+		results := testGroup(t, s, "g").GetMany(context.Background(), keys)
+		for _, key := range keys[:len(keys)-1] {
+			if r := results[key]; r.Err != nil || r.Value.String() != key {
+				t.Errorf("node %s: expected %q, got %q %v", s.self, key, r.Value.String(), r.Err)
+			}
+		}
+		if err := results["missing"].Err; err != cache.ErrKeyNotFound {
+			t.Errorf("node %s: expected ErrKeyNotFound, got %v", s.self, err)
+		}
+	}
+	for _, s := range servers {
+		if s.RingDrift() != 0 {
+			t.Errorf("node %s: expected batches to be routed to owners, got drift %d", s.self, s.RingDrift())
+		}
+	}
+}
+
+func TestCluster_CompareAndSwap(t *testing.T) {
+	servers := newTestCluster(t, 3)
+	for i := range 10 {
+		key := strings.Repeat("k", i+1)
+		writer, reader := testGroup(t, servers[0], "g"), testGroup(t, servers[i%3], "g")
+
+		version, err := writer.CompareAndSwap(key, cache.NewByteView([]byte("a")), 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.CompareAndSwap(key, cache.NewByteView([]byte("b")), 0, 0); err != cache.ErrVersionMismatch {
+			t.Errorf("%s: expected ErrVersionMismatch creating an existing key, got %v", key, err)
+		}
+
+		v, got, err := reader.GetVersion(context.Background(), key)
+		if err != nil || v.String() != "a" || got != version {
+			t.Fatalf("%s: expected a at version %d, got %q at %d %v", key, version, v.String(), got, err)
+		}
+		next, err := reader.CompareAndSwap(key, cache.NewByteView([]byte("c")), got, 0)
+		if err != nil || next <= version {
+			t.Fatalf("%s: expected a newer version than %d, got %d %v", key, version, next, err)
+		}
+		if _, err := writer.CompareAndSwap(key, cache.NewByteView([]byte("d")), version, 0); err != cache.ErrVersionMismatch {
+			t.Errorf("%s: expected ErrVersionMismatch with an old version, got %v", key, err)
+		}
+		if v, err := writer.Get(key); err != nil || v.String() != "c" {
+			t.Errorf("%s: expected c, got %q %v", key, v.String(), err)
+		}
+	}
+}
+
+func TestCluster_Incr(t *testing.T) {
+	servers := newTestCluster(t, 3)
+	for i := range 10 {
+		key := strings.Repeat("c", i+1)
+		for j := range 9 {
+			n, err := testGroup(t, servers[j%3], "g").Incr(key, 2, 0, 0)
+			if err != nil || n != int64(2*j) {
+				t.Fatalf("%s: expected %d, got %d %v", key, 2*j, n, err)
+			}
+		}
+		if n, err := testGroup(t, servers[0], "g").Decr(key, 6, 0, 0); err != nil || n != 10 {
+			t.Errorf("%s: expected 10, got %d %v", key, n, err)
+		}
+		if v, err := testGroup(t, servers[1], "g").Get(key); err != nil || v.String() != "10" {
+			t.Errorf("%s: expected counter stored as 10, got %q %v", key, v.String(), err)
+		}
+	}
+
+	testGroup(t, servers[0], "g").Add("text", cache.NewByteView([]byte("abc")))
+	for _, s := range servers {
+		if _, err := testGroup(t, s, "g").Incr("text", 1, 0, 0); err != cache.ErrInvalidCounter {
+			t.Errorf("node %s: expected ErrInvalidCounter, got %v", s.self, err)
+		}
+	}
+}
+
+func TestCluster_InvalidateTag(t *testing.T) {
+	servers := newTestCluster(t, 3)
+	var keys []string
+	for i := range 20 {
+		key := strings.Repeat("t", i+1)
+		keys = append(keys, key)
+		tag := "odd"
+		if i%2 == 0 {
+			tag = "even"
+		}
+		if err := testGroup(t, servers[i%3], "g").AddWithTTL(key, cache.NewByteView([]byte(key)), 0, "all", tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := testGroup(t, servers[1], "g").InvalidateTag("even"); err != nil || n != 10 {
+		t.Fatalf("expected 10 entries removed across the cluster, got %d %v", n, err)
+	}
+	for i, key := range keys {
+		_, err := testGroup(t, servers[2], "g").Get(key)
+		if i%2 == 0 && err != cache.ErrKeyNotFound {
+			t.Errorf("%s: expected to be invalidated, got %v", key, err)
+		} else if i%2 == 1 && err != nil {
+			t.Errorf("%s: expected to be kept, got %v", key, err)
+		}
+	}
+	if n, err := testGroup(t, servers[0], "g").InvalidateTag("all"); err != nil || n != 10 {
+		t.Errorf("expected the remaining 10 entries removed, got %d %v", n, err)
+	}
+}
@@ -1,13 +1,16 @@
 package http
 
 import (
-	"bytes"
-	"crypto/sha1"
-	"encoding/binary"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 	"zencache/internal/cache"
 	"zencache/internal/config"
 	"zencache/internal/consistenthash"
@@ -17,24 +20,6 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-type httpGetter struct {
-	baseURL string
-}
-
-// 从远程获取
-func (h *httpGetter) Get(group string, key string) ([]byte, error) {
-	requestUrl := fmt.Sprint(h.baseURL + v1.GET_KEY)
-	response, err := http.Post(requestUrl, "application/json", bytes.NewBuffer([]byte(fmt.Sprintf(`{"group":%s,"key":%s}`, group, key))))
-	if err != nil {
-		return nil, err
-	}
-	defer response.Body.Close()
-	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("response status :%s", response.Status)
-	}
-	return io.ReadAll(response.Body)
-}
-
 type Server struct {
 	ginEngine       *gin.Engine
 	cacheEngine     *cache.Engine
@@ -44,18 +29,28 @@ type Server struct {
 	mutex           sync.Mutex
 	peersHttpGetter map[string]*httpGetter
 	peers           *consistenthash.Map
+	peerClient      *http.Client // 所有httpGetter共享，复用与各节点的连接
+	ringDrift       atomic.Int64 // 收到的不属于本节点的转发请求数
+	conf            *config.Config
 }
 
 // NewWithConfig 使用配置创建新的Server实例
 func NewWithConfig(conf *config.Config) *Server {
 	// 创建缓存引擎
-	cacheEngine := cache.NewEngine()
+	cacheEngine := cache.NewEngineWithConfig(&conf.Cache)
 
 	// 创建一致性哈希实例
-	peers := consistenthash.New(conf, func(b []byte) uint32 {
-		hash := sha1.Sum(b)
-		return binary.LittleEndian.Uint32(hash[:4])
-	})
+	hash, err := consistenthash.HashByName(conf.Cluster.HashFunc)
+	if err != nil {
+		log.Printf("%v, fallback to sha1", err)
+		hash, _ = consistenthash.HashByName("sha1")
+	}
+	peers := consistenthash.New(conf, hash)
+
+	scheme := conf.Cluster.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
 
 	// 创建HTTP服务器
 	ginEngine := gin.Default()
@@ -65,14 +60,57 @@ func NewWithConfig(conf *config.Config) *Server {
 		ginEngine:       ginEngine,
 		cacheEngine:     cacheEngine,
 		addr:            fmt.Sprintf("%s:%d", conf.HTTP.Address, conf.HTTP.Port),
+		self:            conf.Cluster.Self,
+		baseUrl:         scheme + "://",
 		peers:           peers,
 		peersHttpGetter: make(map[string]*httpGetter),
+		peerClient: newPeerClient(time.Duration(conf.Cluster.PeerTimeoutMs)*time.Millisecond,
+			conf.Cluster.MaxIdleConnsPerPeer),
+		conf: conf,
+	}
+
+	// 构建集群的一致性哈希环，本节点也参与分配
+	if len(conf.Cluster.Peers) > 0 {
+		nodes := slices.Clone(conf.Cluster.Peers)
+		if s.self != "" && !slices.Contains(nodes, s.self) {
+			nodes = append(nodes, s.self)
+		}
+		s.SetNodes(nodes...)
+	}
+
+	// 新建的Group都通过本节点选择远程节点
+	s.cacheEngine.RegisterPicker(s)
+
+	// 预先创建配置文件中声明的Group
+	for name := range conf.Cache.Groups {
+		if _, err := s.group(name); err != nil {
+			log.Printf("group %s: %v", name, err)
+		}
 	}
 
 	// 注册路由
 	s.ginEngine.POST(v1.STORE_KEY, s.handleStoreKey)
 	s.ginEngine.POST(v1.GET_KEY, s.handleGetKey)
 	s.ginEngine.POST(v1.DELETE_KEY, s.handleDeleteKey)
+	s.ginEngine.POST(v1.GET_MANY, s.handleGetMany)
+	s.ginEngine.POST(v1.STORE_MANY, s.handleStoreMany)
+	s.ginEngine.POST(v1.COMPARE_AND_SWAP, s.handleCompareAndSwap)
+	s.ginEngine.POST(v1.INCR, s.handleIncr)
+	s.ginEngine.POST(v1.DECR, s.handleDecr)
+	s.ginEngine.POST(v1.INVALIDATE_TAG, s.handleInvalidateTag)
+	s.ginEngine.POST(v1.ADD_GROUP, s.handleAddGroup)
+	s.ginEngine.POST(v1.REMOVE_GROUP, s.handleRemoveGroup)
+	s.ginEngine.GET(v1.LIST_GROUPS, s.handleListGroups)
+	s.ginEngine.POST(v1.FLUSH_GROUP, s.handleFlushGroup)
+	s.ginEngine.POST(v1.RESIZE_GROUP, s.handleResizeGroup)
+	s.ginEngine.POST(v1.PEER_GET_KEY, s.handlePeerGetKey)
+	s.ginEngine.POST(v1.PEER_STORE_KEY, s.handlePeerStoreKey)
+	s.ginEngine.POST(v1.PEER_DELETE_KEY, s.handlePeerDeleteKey)
+	s.ginEngine.POST(v1.PEER_GET_MANY, s.handlePeerGetMany)
+	s.ginEngine.POST(v1.PEER_STORE_MANY, s.handlePeerStoreMany)
+	s.ginEngine.POST(v1.PEER_COMPARE_AND_SWAP, s.handlePeerCompareAndSwap)
+	s.ginEngine.POST(v1.PEER_INCR, s.handlePeerIncr)
+	s.ginEngine.POST(v1.PEER_INVALIDATE_TAG, s.handlePeerInvalidateTag)
 
 	return s
 }
@@ -88,21 +126,26 @@ func (s *Server) PickPeer(key string) (peers.PeerGetter, bool) {
 	return getter, ok
 }
 
-var _ peers.PeersPicker = (*Server)(nil)
-
-func New(addr string) *Server {
-	cacheEngine := cache.NewEngine()
-	ginEngine := gin.Default()
-	s := &Server{
-		ginEngine:   ginEngine,
-		cacheEngine: cacheEngine,
-		addr:        addr,
+// ListPeers 返回除本节点外的所有节点
+func (s *Server) ListPeers() []peers.PeerGetter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := make([]peers.PeerGetter, 0, len(s.peersHttpGetter))
+	for node, getter := range s.peersHttpGetter {
+		if node != s.self {
+			list = append(list, getter)
+		}
 	}
+	return list
+}
 
-	s.ginEngine.POST(v1.STORE_KEY, s.handleStoreKey)
-	s.ginEngine.POST(v1.GET_KEY, s.handleGetKey)
-	s.ginEngine.POST(v1.DELETE_KEY, s.handleDeleteKey)
+var _ peers.PeerLister = (*Server)(nil)
 
+// New 使用默认配置创建单机模式的Server实例
+func New(addr string) *Server {
+	conf := config.DefaultConfig
+	s := NewWithConfig(&conf)
+	s.addr = addr
 	return s
 }
 
@@ -113,10 +156,28 @@ func (s *Server) SetNodes(nodes ...string) {
 	for _, node := range nodes {
 		s.peersHttpGetter[node] = &httpGetter{
 			baseURL: s.baseUrl + node,
+			origin:  s.self,
+			client:  s.peerClient,
 		}
 	}
 }
 
+// group 获取Group，不存在时按配置创建，只用于写入请求，读取和删除不会创建Group。
+// Engine的总容量不足时返回ErrMemoryBudgetExceeded，自动创建的Group数量达到上限时返回ErrTooManyGroups
+func (s *Server) group(name string) (*cache.Group, error) {
+	group, _, err := s.cacheEngine.GetOrAddGroup(name, nil, s.conf.Cache.MaxBytes)
+	return group, err
+}
+
+// jsonError 将错误按对应的状态码写入JSON响应
+func jsonError(c *gin.Context, err error) {
+	statusCode := errorStatus(err)
+	c.JSON(statusCode, v1.Response{
+		Code:    int32(statusCode),
+		Message: err.Error(),
+	})
+}
+
 func (s *Server) handleStoreKey(c *gin.Context) {
 	var req v1.StoreRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -127,14 +188,14 @@ func (s *Server) handleStoreKey(c *gin.Context) {
 		return
 	}
 
-	group := s.cacheEngine.GetGroup(req.Group)
-	if group == nil {
-		s.cacheEngine.AddGroup(req.Group, nil, 1<<20) // 默认1MB
-		group = s.cacheEngine.GetGroup(req.Group)
-		group.RegisterPicker(s)
+	group, err := s.group(req.Group)
+	if err != nil {
+		jsonError(c, err)
+		return
 	}
 
-	if err := group.Add(req.Key, cache.NewByteView(req.Value)); err != nil {
+	ttl := time.Duration(req.TtlMs) * time.Millisecond
+	if err := group.AddWithTTL(req.Key, cache.NewByteView(req.Value), ttl, req.Tags...); err != nil {
 		c.JSON(http.StatusInternalServerError, v1.Response{
 			Code:    http.StatusInternalServerError,
 			Message: err.Error(),
@@ -158,33 +219,73 @@ func (s *Server) handleGetKey(c *gin.Context) {
 		return
 	}
 
+	// 读取不会创建Group，本节点没有该Group时转发给key所属的节点，key属于本节点时返回404
+	ctx := c.Request.Context()
 	group := s.cacheEngine.GetGroup(req.Group)
-	if group == nil {
-		c.JSON(http.StatusNotFound, v1.Response{
-			Code:    http.StatusNotFound,
-			Message: "group not found",
-		})
-		return
+	var value cache.ByteView
+	var version uint64
+	var err error
+	switch {
+	case group == nil && req.WithVersion:
+		value, version, err = cache.GetVersionOnPeer(ctx, s, req.Group, req.Key)
+	case group == nil:
+		value, err = cache.GetOnPeer(ctx, s, req.Group, req.Key)
+	case req.WithVersion:
+		value, version, err = group.GetVersion(ctx, req.Key)
+	default:
+		value, err = group.GetContext(ctx, req.Key)
 	}
-
-	value, err := group.Get(req.Key)
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-		if err == cache.ErrKeyNotFound {
-			statusCode = http.StatusNotFound
-		}
-		c.JSON(statusCode, v1.Response{
-			Code:    int32(statusCode),
-			Message: err.Error(),
-		})
+		jsonError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, v1.Response{
+	writeValue(c, value, version)
+}
+
+// writeValue 写入v1.Response格式的JSON响应，除data外的字段由v1.Response序列化，
+// value以base64编码直接从ByteView写入data字段，不复制数据
+func writeValue(c *gin.Context, value cache.ByteView, version uint64) {
+	head, err := json.Marshal(&v1.Response{
 		Code:    http.StatusOK,
 		Message: "success",
-		Data:    value.ByteSlices(),
+		Version: version,
 	})
+	if err != nil {
+		jsonError(c, err)
+		return
+	}
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	// 去掉结尾的}，在其前面插入data字段
+	w := &stickyWriter{w: c.Writer}
+	w.Write(head[:len(head)-1])
+	if value.Len() > 0 {
+		io.WriteString(w, `,"data":"`)
+		encoder := base64.NewEncoder(base64.StdEncoding, w)
+		value.WriteTo(encoder)
+		encoder.Close()
+		io.WriteString(w, `"`)
+	}
+	io.WriteString(w, "}")
+	if w.err != nil {
+		c.Error(w.err)
+	}
+}
+
+// stickyWriter 记录第一次写入失败的错误，之后的写入直接返回该错误，不再写入
+type stickyWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *stickyWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(p)
+	w.err = err
+	return n, err
 }
 
 func (s *Server) handleDeleteKey(c *gin.Context) {
@@ -197,16 +298,18 @@ func (s *Server) handleDeleteKey(c *gin.Context) {
 		return
 	}
 
-	group := s.cacheEngine.GetGroup(req.Group)
-	if group == nil {
-		c.JSON(http.StatusNotFound, v1.Response{
-			Code:    http.StatusNotFound,
-			Message: "group not found",
-		})
+	// 删除不会创建Group，本节点没有该Group时转发给key所属的节点，key属于本节点时返回404
+	var err error
+	if group := s.cacheEngine.GetGroup(req.Group); group != nil {
+		err = group.Delete(req.Key)
+	} else {
+		err = cache.DeleteOnPeer(s, req.Group, req.Key)
+	}
+	if err != nil {
+		jsonError(c, err)
 		return
 	}
 
-	// TODO: 实现删除键的功能
 	c.JSON(http.StatusOK, v1.Response{
 		Code:    http.StatusOK,
 		Message: "success",
@@ -3,14 +3,250 @@ package http
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
+	"zencache/internal/cache"
+	"zencache/internal/config"
 	v1 "zencache/internal/transport/api/v1"
 
 	"github.com/gin-gonic/gin"
 )
 
+func TestNewWithConfig_Cluster(t *testing.T) {
+	gin.SetMode("release")
+	conf := config.DefaultConfig
+	conf.Cluster = config.ClusterConfig{
+		Self:     "127.0.0.1:8001",
+		Peers:    []string{"127.0.0.1:8002", "127.0.0.1:8003"},
+		Scheme:   "http",
+		HashFunc: "fnv",
+	}
+	s := NewWithConfig(&conf)
+
+	if len(s.peersHttpGetter) != 3 {
+		t.Fatalf("expected getters for all 3 nodes including self, got %d", len(s.peersHttpGetter))
+	}
+	if got := s.peersHttpGetter["127.0.0.1:8002"].baseURL; got != "http://127.0.0.1:8002" {
+		t.Errorf("unexpected peer base url %q", got)
+	}
+	local, remote := 0, 0
+	for i := range 100 {
+		if _, ok := s.PickPeer(fmt.Sprint("key", i)); ok {
+			remote++
+		} else {
+			local++
+		}
+	}
+	if local == 0 || remote == 0 {
+		t.Errorf("expected keys to be spread over self and peers, got local=%d remote=%d", local, remote)
+	}
+}
+
+// postJSON 向Server发送JSON请求，返回状态码和响应体
+func postJSON(s *Server, method string, path string, req any) (int, []byte) {
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(method, path, bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.ginEngine.ServeHTTP(w, r)
+	return w.Code, w.Body.Bytes()
+}
+
+func TestServer_AdminGroups(t *testing.T) {
+	gin.SetMode("release")
+	s := New(":0")
+
+	if code, _ := postJSON(s, http.MethodPost, v1.ADD_GROUP, &v1.GroupRequest{Group: "g", MaxBytes: 1 << 10}); code != http.StatusOK {
+		t.Fatalf("expected add to succeed, got %d", code)
+	}
+	if code, _ := postJSON(s, http.MethodPost, v1.ADD_GROUP, &v1.GroupRequest{Group: "g"}); code != http.StatusConflict {
+		t.Errorf("expected duplicate add to conflict, got %d", code)
+	}
+	if code, _ := postJSON(s, http.MethodPost, v1.ADD_GROUP, &v1.GroupRequest{Group: "g", Replace: true}); code != http.StatusOK {
+		t.Errorf("expected replace to succeed, got %d", code)
+	}
+
+	testGroup(t, s, "g").Add("k", cache.NewByteView(make([]byte, 100)))
+	if code, _ := postJSON(s, http.MethodPost, v1.RESIZE_GROUP, &v1.GroupRequest{Group: "g", MaxBytes: 50}); code != http.StatusOK {
+		t.Errorf("expected resize to succeed, got %d", code)
+	}
+	if st := testGroup(t, s, "g").Stats(); st.MaxBytes != 50 || st.Items != 0 {
+		t.Errorf("expected resize to evict the oversized entry, got %+v", st)
+	}
+	if code, _ := postJSON(s, http.MethodPost, v1.FLUSH_GROUP, &v1.GroupRequest{Group: "g"}); code != http.StatusOK {
+		t.Errorf("expected flush to succeed, got %d", code)
+	}
+
+	code, body := postJSON(s, http.MethodGet, v1.LIST_GROUPS, nil)
+	var list v1.ListGroupsResponse
+	if err := json.Unmarshal(body, &list); err != nil || code != http.StatusOK || len(list.Groups) != 1 || list.Groups[0] != "g" {
+		t.Errorf("expected [g], got %d %s", code, body)
+	}
+
+	if code, _ := postJSON(s, http.MethodPost, v1.REMOVE_GROUP, &v1.GroupRequest{Group: "g"}); code != http.StatusOK {
+		t.Errorf("expected remove to succeed, got %d", code)
+	}
+	if code, _ := postJSON(s, http.MethodPost, v1.REMOVE_GROUP, &v1.GroupRequest{Group: "g"}); code != http.StatusNotFound {
+		t.Errorf("expected removing a missing group to return 404, got %d", code)
+	}
+}
+
+func TestServer_GetKeyJSON(t *testing.T) {
+	gin.SetMode("release")
+	s := New(":0")
+	value := []byte{0, 1, 2, '"', '}', 255}
+	if code, _ := postJSON(s, http.MethodPost, v1.STORE_KEY, &v1.StoreRequest{Group: "g", Key: "k", Value: value}); code != http.StatusOK {
+		t.Fatalf("expected store to succeed, got %d", code)
+	}
+
+	code, body := postJSON(s, http.MethodPost, v1.GET_KEY, &v1.GetRequest{Group: "g", Key: "k"})
+	var resp v1.Response
+	if err := json.Unmarshal(body, &resp); err != nil || code != http.StatusOK {
+		t.Fatalf("expected a JSON response, got %d %s %v", code, body, err)
+	}
+	if resp.Code != http.StatusOK || !bytes.Equal(resp.Data, value) {
+		t.Errorf("expected %v, got %d %v", value, resp.Code, resp.Data)
+	}
+}
+
+// failingWriter 的每次写入都失败并记录调用次数
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("connection reset")
+}
+
+func TestWriteValue_StopsOnWriteError(t *testing.T) {
+	gin.SetMode("release")
+	w := &failingWriter{ResponseRecorder: httptest.NewRecorder()}
+	c, _ := gin.CreateTestContext(w)
+	writeValue(c, cache.NewByteView(make([]byte, 1<<16)), 1)
+	if w.writes != 1 {
+		t.Errorf("expected writing to stop after the first failure, got %d writes", w.writes)
+	}
+	if len(c.Errors) != 1 {
+		t.Errorf("expected the write error to be recorded, got %v", c.Errors)
+	}
+}
+
+func TestServer_GetterPanicRecovered(t *testing.T) {
+	gin.SetMode("release")
+	s := New(":0")
+	s.cacheEngine.AddGroup("panic", cache.GetterFunc(func(key string) ([]byte, error) {
+		panic("getter failed")
+	}), 1<<10)
+
+	// 回源在单独的goroutine中执行，panic应传回处理请求的goroutine，由gin恢复
+	if code, _ := postJSON(s, http.MethodPost, v1.GET_KEY, &v1.GetRequest{Group: "panic", Key: "k"}); code != http.StatusInternalServerError {
+		t.Errorf("expected a panicking getter to return 500, got %d", code)
+	}
+}
+
+func TestServer_UnknownGroup(t *testing.T) {
+	gin.SetMode("release")
+	s := New(":0")
+
+	if code, _ := postJSON(s, http.MethodPost, v1.GET_KEY, &v1.GetRequest{Group: "nope", Key: "k"}); code != http.StatusNotFound {
+		t.Errorf("expected get from an unknown group to return 404, got %d", code)
+	}
+	if code, _ := postJSON(s, http.MethodPost, v1.DELETE_KEY, &v1.DeleteRequest{Group: "nope", Key: "k"}); code != http.StatusNotFound {
+		t.Errorf("expected delete from an unknown group to return 404, got %d", code)
+	}
+	if groups := s.cacheEngine.ListGroups(); slices.Contains(groups, "nope") {
+		t.Errorf("expected reads and deletes not to create groups, got %v", groups)
+	}
+}
+
+func TestServer_GroupCreationLimited(t *testing.T) {
+	gin.SetMode("release")
+	conf := config.DefaultConfig
+	conf.Cache.MaxGroups = 1
+	s := NewWithConfig(&conf)
+
+	postJSON(s, http.MethodPost, v1.GET_MANY, &v1.GetManyRequest{Group: "read", Keys: []string{"k"}})
+	postJSON(s, http.MethodPost, v1.INVALIDATE_TAG, &v1.InvalidateTagRequest{Group: "read", Tag: "t"})
+	if groups := s.cacheEngine.ListGroups(); len(groups) != 0 {
+		t.Errorf("expected reads not to create groups, got %v", groups)
+	}
+
+	if code, _ := postJSON(s, http.MethodPost, v1.STORE_KEY, &v1.StoreRequest{Group: "a", Key: "k", Value: []byte("v")}); code != http.StatusOK {
+		t.Fatalf("expected the first store to create a group, got %d", code)
+	}
+	if code, _ := postJSON(s, http.MethodPost, v1.STORE_KEY, &v1.StoreRequest{Group: "b", Key: "k", Value: []byte("v")}); code != http.StatusInsufficientStorage {
+		t.Errorf("expected stores to new groups to be rejected past the limit, got %d", code)
+	}
+}
+
+func TestServer_CompareAndSwapJSON(t *testing.T) {
+	gin.SetMode("release")
+	s := New(":0")
+
+	cas := func(value string, version uint64) (int, uint64) {
+		code, body := postJSON(s, http.MethodPost, v1.COMPARE_AND_SWAP,
+			&v1.CompareAndSwapRequest{Group: "g", Key: "k", Value: []byte(value), Version: version})
+		var resp v1.Response
+		json.Unmarshal(body, &resp)
+		return code, resp.Version
+	}
+	code, created := cas("a", 0)
+	if code != http.StatusOK || created == 0 {
+		t.Fatalf("expected create to succeed with a version, got %d %d", code, created)
+	}
+	if code, _ := cas("b", 0); code != http.StatusPreconditionFailed {
+		t.Errorf("expected create of an existing key to fail, got %d", code)
+	}
+
+	code, body := postJSON(s, http.MethodPost, v1.GET_KEY, &v1.GetRequest{Group: "g", Key: "k", WithVersion: true})
+	var resp v1.Response
+	if err := json.Unmarshal(body, &resp); err != nil || code != http.StatusOK {
+		t.Fatalf("expected a JSON response, got %d %s %v", code, body, err)
+	}
+	if string(resp.Data) != "a" || resp.Version != created {
+		t.Errorf("expected a at version %d, got %q at %d", created, resp.Data, resp.Version)
+	}
+	if code, _ := cas("c", created); code != http.StatusOK {
+		t.Errorf("expected swap with the current version to succeed, got %d", code)
+	}
+}
+
+func TestServer_IncrDecrJSON(t *testing.T) {
+	gin.SetMode("release")
+	s := New(":0")
+
+	for _, tt := range []struct {
+		path string
+		want int64
+	}{
+		{v1.INCR, 100},
+		{v1.INCR, 103},
+		{v1.DECR, 100},
+	} {
+		code, body := postJSON(s, http.MethodPost, tt.path, &v1.IncrRequest{Group: "g", Key: "k", Delta: 3, Initial: 100})
+		var resp v1.IncrResponse
+		if err := json.Unmarshal(body, &resp); err != nil || code != http.StatusOK {
+			t.Fatalf("expected a JSON response, got %d %s %v", code, body, err)
+		}
+		if resp.Value != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.path, tt.want, resp.Value)
+		}
+	}
+
+	// math.MinInt64取反会溢出
+	code, body := postJSON(s, http.MethodPost, v1.DECR, &v1.IncrRequest{Group: "g", Key: "k", Delta: math.MinInt64})
+	if code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for delta MinInt64, got %d %s", code, body)
+	}
+}
+
 func BenchmarkServer(b *testing.B) {
 	gin.SetMode("release")
 	s := New(":8080")
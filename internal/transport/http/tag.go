@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"zencache/internal/cache"
+	"zencache/internal/peers"
+	v1 "zencache/internal/transport/api/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ peers.PeerTagger = (*httpGetter)(nil)
+
+// InvalidateTag 删除远程节点本地带有tag的缓存，响应体为十进制的删除条目数
+func (h *httpGetter) InvalidateTag(group string, tag string) (int, error) {
+	response, err := h.post(context.Background(), v1.PEER_INVALIDATE_TAG, &v1.InvalidateTagRequest{Group: group, Tag: tag})
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(body))
+}
+
+func (s *Server) handleInvalidateTag(c *gin.Context) {
+	var req v1.InvalidateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, v1.Response{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// 本节点没有该Group时不创建，只广播给其他节点
+	var removed int
+	var err error
+	if group := s.cacheEngine.GetGroup(req.Group); group != nil {
+		removed, err = group.InvalidateTag(req.Tag)
+	} else {
+		removed, err = cache.InvalidateTagOnPeers(s, req.Group, req.Tag)
+	}
+	if err != nil {
+		jsonError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, v1.InvalidateTagResponse{
+		Code:    http.StatusOK,
+		Message: "success",
+		Removed: int64(removed),
+	})
+}
+
+func (s *Server) handlePeerInvalidateTag(c *gin.Context) {
+	var req v1.InvalidateTagRequest
+	if !bindProto(c, &req) {
+		return
+	}
+	if req.Tag == "" {
+		c.String(http.StatusBadRequest, "tag is required")
+		return
+	}
+	removed := 0
+	if group := s.cacheEngine.GetGroup(req.Group); group != nil {
+		removed = group.InvalidateTagLocal(req.Tag)
+	}
+	c.String(http.StatusOK, strconv.Itoa(removed))
+}